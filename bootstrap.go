@@ -0,0 +1,245 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ipsn/go-libtor/control"
+)
+
+// ErrTerminated is returned when waiting on an embedded tor that terminated.
+var ErrTerminated = errors.New("embedded tor terminated")
+
+// ErrBootstrapStalled is returned when tor makes no bootstrap progress for a
+// while after warning about a bootstrap problem.
+var ErrBootstrapStalled = errors.New("no progress since bootstrap warning")
+
+// bootstrapStallTimeout is the time tor has to make bootstrap progress after it
+// warned about a problem, before WaitBootstrapped gives up on it.
+var bootstrapStallTimeout = 5 * time.Minute
+
+// BootstrapPhase is the tag of a phase tor goes through while bootstrapping.
+type BootstrapPhase string
+
+// Phases of tor's bootstrap process, in order.
+const (
+	BootstrapStarting              BootstrapPhase = "starting"
+	BootstrapConnDir               BootstrapPhase = "conn_dir"
+	BootstrapHandshakeDir          BootstrapPhase = "handshake_dir"
+	BootstrapOnehopCreate          BootstrapPhase = "onehop_create"
+	BootstrapRequestingStatus      BootstrapPhase = "requesting_status"
+	BootstrapLoadingStatus         BootstrapPhase = "loading_status"
+	BootstrapLoadingKeys           BootstrapPhase = "loading_keys"
+	BootstrapRequestingDescriptors BootstrapPhase = "requesting_descriptors"
+	BootstrapLoadingDescriptors    BootstrapPhase = "loading_descriptors"
+	BootstrapConnOR                BootstrapPhase = "conn_or"
+	BootstrapHandshakeOR           BootstrapPhase = "handshake_or"
+	BootstrapCircuitCreate         BootstrapPhase = "circuit_create"
+	BootstrapDone                  BootstrapPhase = "done"
+)
+
+// BootstrapStatus is a bootstrap progress or problem report from tor.
+type BootstrapStatus struct {
+	Phase    BootstrapPhase // Phase tor is in (or stuck at)
+	Progress int            // Percentage of the bootstrap process completed
+	Summary  string         // Human readable description of the phase
+
+	Warning        string // Human readable description of the problem
+	Reason         string // Machine readable reason of the problem
+	Count          int    // Number of problems encountered while bootstrapping
+	Recommendation string // Whether to report the problem: "warn" or "ignore"
+	HostID         string // Identity of the relay the problem occurred with
+	HostAddr       string // Address of the relay the problem occurred with
+}
+
+// Problem returns whether the status reports a bootstrap problem instead of
+// progress.
+func (s *BootstrapStatus) Problem() bool {
+	return s.Recommendation != ""
+}
+
+// Failed returns whether the status reports a problem tor deems severe enough
+// to warn the user about. Tor keeps retrying afterwards, so bootstrapping may
+// still succeed if the problem was transient.
+func (s *BootstrapStatus) Failed() bool {
+	return s.Recommendation == "warn"
+}
+
+// Done returns whether the status reports a completed bootstrap.
+func (s *BootstrapStatus) Done() bool {
+	return s.Progress >= 100
+}
+
+// String implements fmt.Stringer, formatting the status similarly to tor's logs.
+func (s *BootstrapStatus) String() string {
+	if !s.Problem() {
+		return fmt.Sprintf("%d%% (%s): %s", s.Progress, s.Phase, s.Summary)
+	}
+	return fmt.Sprintf("stuck at %d%% (%s): %s; %s (reason %s, count %d, host %s at %s)",
+		s.Progress, s.Phase, s.Summary, s.Warning, s.Reason, s.Count, s.HostID, s.HostAddr)
+}
+
+// parseBootstrapStatus parses the text of a bootstrap client status event (or
+// the bootstrap phase info), returning nil if the status is not a bootstrap one.
+func parseBootstrapStatus(text string) *BootstrapStatus {
+	args, keywords := control.ParseArguments(text)
+	if len(args) < 2 || args[1] != "BOOTSTRAP" {
+		return nil
+	}
+	progress, _ := strconv.Atoi(keywords["PROGRESS"])
+	count, _ := strconv.Atoi(keywords["COUNT"])
+
+	return &BootstrapStatus{
+		Phase:          BootstrapPhase(keywords["TAG"]),
+		Progress:       progress,
+		Summary:        keywords["SUMMARY"],
+		Warning:        keywords["WARNING"],
+		Reason:         keywords["REASON"],
+		Count:          count,
+		Recommendation: keywords["RECOMMENDATION"],
+		HostID:         keywords["HOSTID"],
+		HostAddr:       keywords["HOSTADDR"],
+	}
+}
+
+// BootstrapError is returned if tor fails to bootstrap, or doesn't manage to do
+// so in time.
+type BootstrapError struct {
+	Status  *BootstrapStatus // Last progress report before giving up, if any
+	Problem *BootstrapStatus // Last problem report before giving up, if any
+	Err     error            // Reason for giving up
+}
+
+// Error implements error, formatting the bootstrap failure.
+func (e *BootstrapError) Error() string {
+	msg := "tor bootstrap stalled"
+	if e.Status != nil {
+		msg += fmt.Sprintf(" at %v", e.Status)
+	}
+	msg += fmt.Sprintf(": %v", e.Err)
+	if e.Problem != nil {
+		msg += fmt.Sprintf(" (last problem: %s, %s)", e.Problem.Warning, e.Problem.Reason)
+	}
+	return msg
+}
+
+// Unwrap returns the reason for giving up on the bootstrap, to support errors.Is
+// checks against context errors.
+func (e *BootstrapError) Unwrap() error {
+	return e.Err
+}
+
+// Bootstrap streams tor's bootstrap progress and problem reports, starting with
+// the current status. The channel is closed after bootstrapping completes, the
+// context is cancelled or tor terminates.
+func (t *Tor) Bootstrap(ctx context.Context) (<-chan *BootstrapStatus, error) {
+	// Subscribe to status updates before retrieving the current one so none is missed
+	events := make(chan *control.Event, 16)
+
+	sub, err := t.ctrl.Subscribe(ctx, events, "STATUS_CLIENT")
+	if err != nil {
+		return nil, err
+	}
+	info, err := t.ctrl.GetInfo(ctx, "status/bootstrap-phase")
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+	updates := make(chan *BootstrapStatus, 1)
+	go func() {
+		defer close(updates)
+		defer sub.Close()
+
+		status := parseBootstrapStatus(info["status/bootstrap-phase"])
+		for {
+			if status != nil {
+				select {
+				case updates <- status:
+				case <-ctx.Done():
+					return
+				}
+				if status.Done() {
+					return
+				}
+			}
+			select {
+			case event := <-events:
+				status = parseBootstrapStatus(event.Text)
+			case <-ctx.Done():
+				return
+			case <-t.ctrl.Closed():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// WaitBootstrapped blocks until tor finishes bootstrapping. A *BootstrapError is
+// returned if tor does not manage to do so before the context is cancelled or tor
+// terminates, or if it makes no progress for minutes after warning about a
+// problem (ErrBootstrapStalled). Problems are otherwise only recorded, as tor
+// keeps retrying and often recovers from them.
+func (t *Tor) WaitBootstrapped(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	updates, err := t.Bootstrap(ctx)
+	if err != nil {
+		return err
+	}
+	return waitBootstrapped(ctx, updates, bootstrapStallTimeout)
+}
+
+// waitBootstrapped consumes bootstrap status updates until one reports a done
+// bootstrap, giving up if the context is cancelled, the updates end, or no
+// progress is made within the stall timeout after a warning.
+func waitBootstrapped(ctx context.Context, updates <-chan *BootstrapStatus, stallTimeout time.Duration) error {
+	var (
+		status, problem *BootstrapStatus
+		stall           *time.Timer // Timer running while stuck after a warning
+		stalled         <-chan time.Time
+	)
+	defer func() {
+		if stall != nil {
+			stall.Stop()
+		}
+	}()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return &BootstrapError{Status: status, Problem: problem, Err: err}
+				}
+				return &BootstrapError{Status: status, Problem: problem, Err: ErrTerminated}
+			}
+			switch {
+			case update.Done():
+				return nil
+			case update.Problem():
+				problem = update
+				if update.Failed() && stall == nil {
+					stall = time.NewTimer(stallTimeout)
+					stalled = stall.C
+				}
+			default:
+				status = update
+				if stall != nil {
+					stall.Stop()
+					stall, stalled = nil, nil
+				}
+			}
+		case <-stalled:
+			return &BootstrapError{Status: status, Problem: problem, Err: ErrBootstrapStalled}
+		case <-ctx.Done():
+			return &BootstrapError{Status: status, Problem: problem, Err: ctx.Err()}
+		}
+	}
+}
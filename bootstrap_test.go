@@ -0,0 +1,140 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Tests that bootstrap progress and problem reports are parsed from the client
+// status events and bootstrap phase info tor 0.3.5 emits.
+func TestParseBootstrapStatus(t *testing.T) {
+	tests := []struct {
+		text   string
+		status *BootstrapStatus
+	}{
+		{
+			text:   `NOTICE BOOTSTRAP PROGRESS=0 TAG=starting SUMMARY="Starting"`,
+			status: &BootstrapStatus{Phase: BootstrapStarting, Summary: "Starting"},
+		},
+		{
+			text:   `NOTICE BOOTSTRAP PROGRESS=80 TAG=conn_or SUMMARY="Connecting to the Tor network"`,
+			status: &BootstrapStatus{Phase: BootstrapConnOR, Progress: 80, Summary: "Connecting to the Tor network"},
+		},
+		{
+			text:   `NOTICE BOOTSTRAP PROGRESS=100 TAG=done SUMMARY="Done"`,
+			status: &BootstrapStatus{Phase: BootstrapDone, Progress: 100, Summary: "Done"},
+		},
+		{
+			text: `WARN BOOTSTRAP PROGRESS=5 TAG=conn_dir SUMMARY="Connecting to directory server" WARNING="Connection refused" REASON=CONNECTREFUSED COUNT=3 RECOMMENDATION=warn HOSTID="7EA6EAD6FD83083C538F44038BBFA077587DD755" HOSTADDR="194.109.206.212:443"`,
+			status: &BootstrapStatus{
+				Phase:          BootstrapConnDir,
+				Progress:       5,
+				Summary:        "Connecting to directory server",
+				Warning:        "Connection refused",
+				Reason:         "CONNECTREFUSED",
+				Count:          3,
+				Recommendation: "warn",
+				HostID:         "7EA6EAD6FD83083C538F44038BBFA077587DD755",
+				HostAddr:       "194.109.206.212:443",
+			},
+		},
+		{
+			text: `WARN BOOTSTRAP PROGRESS=10 TAG=handshake_dir SUMMARY="Finishing handshake with directory server" WARNING="DONE" REASON=DONE COUNT=1 RECOMMENDATION=ignore HOSTID="?" HOSTADDR="?"`,
+			status: &BootstrapStatus{
+				Phase:          BootstrapHandshakeDir,
+				Progress:       10,
+				Summary:        "Finishing handshake with directory server",
+				Warning:        "DONE",
+				Reason:         "DONE",
+				Count:          1,
+				Recommendation: "ignore",
+				HostID:         "?",
+				HostAddr:       "?",
+			},
+		},
+		{text: `NOTICE CIRCUIT_ESTABLISHED`},
+		{text: `WARN DANGEROUS_SOCKS PROTOCOL=SOCKS5 ADDRESS=1.2.3.4:80`},
+		{text: ``},
+	}
+	for i, tt := range tests {
+		if have := parseBootstrapStatus(tt.text); !reflect.DeepEqual(have, tt.status) {
+			t.Errorf("test %d: status mismatch: have %+v, want %+v", i, have, tt.status)
+		}
+	}
+}
+
+var (
+	bootstrapProgress = &BootstrapStatus{Phase: BootstrapConnOR, Progress: 80}
+	bootstrapDone     = &BootstrapStatus{Phase: BootstrapDone, Progress: 100}
+	bootstrapWarning  = &BootstrapStatus{Phase: BootstrapConnDir, Progress: 5, Reason: "CONNECTREFUSED", Recommendation: "warn"}
+	bootstrapIgnored  = &BootstrapStatus{Phase: BootstrapConnDir, Progress: 5, Reason: "DONE", Recommendation: "ignore"}
+)
+
+// Tests that bootstrap warnings are tolerated as long as tor makes progress in
+// time afterwards.
+func TestWaitBootstrappedRecovery(t *testing.T) {
+	updates := make(chan *BootstrapStatus, 4)
+	updates <- bootstrapWarning
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		updates <- bootstrapProgress
+		time.Sleep(50 * time.Millisecond)
+		updates <- bootstrapIgnored
+		time.Sleep(50 * time.Millisecond)
+		updates <- bootstrapDone
+	}()
+	if err := waitBootstrapped(context.Background(), updates, time.Second); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+}
+
+// Tests that bootstrapping is given up on if tor makes no progress in time after
+// warning about a problem.
+func TestWaitBootstrappedStall(t *testing.T) {
+	updates := make(chan *BootstrapStatus, 2)
+	updates <- bootstrapProgress
+	updates <- bootstrapWarning
+
+	err := waitBootstrapped(context.Background(), updates, 50*time.Millisecond)
+	berr, ok := err.(*BootstrapError)
+	if !ok {
+		t.Fatalf("error type mismatch: have %T (%v), want *BootstrapError", err, err)
+	}
+	if berr.Err != ErrBootstrapStalled {
+		t.Errorf("reason mismatch: have %v, want %v", berr.Err, ErrBootstrapStalled)
+	}
+	if berr.Status != bootstrapProgress {
+		t.Errorf("status mismatch: have %v, want %v", berr.Status, bootstrapProgress)
+	}
+	if berr.Problem != bootstrapWarning {
+		t.Errorf("problem mismatch: have %v, want %v", berr.Problem, bootstrapWarning)
+	}
+}
+
+// Tests that cancelling the context aborts waiting with an error unwrapping to
+// the context's one.
+func TestWaitBootstrappedCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	updates := make(chan *BootstrapStatus, 2)
+	updates <- bootstrapProgress
+	updates <- bootstrapWarning
+
+	err := waitBootstrapped(ctx, updates, time.Hour)
+	berr, ok := err.(*BootstrapError)
+	if !ok {
+		t.Fatalf("error type mismatch: have %T (%v), want *BootstrapError", err, err)
+	}
+	if berr.Unwrap() != context.DeadlineExceeded {
+		t.Errorf("reason mismatch: have %v, want %v", berr.Unwrap(), context.DeadlineExceeded)
+	}
+	if berr.Status != bootstrapProgress || berr.Problem != bootstrapWarning {
+		t.Errorf("reports mismatch: have %v / %v, want %v / %v", berr.Status, berr.Problem, bootstrapProgress, bootstrapWarning)
+	}
+}
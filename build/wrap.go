@@ -10,6 +10,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"go/format"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -39,7 +40,9 @@ func main() {
 			}
 			continue
 		}
-		if strings.HasSuffix(file.Name(), ".go") {
+		// Only the thin wrapper around the internal package and the typed tor
+		// options are generated in the repository root, the rest is hand written.
+		if file.Name() == "libtor.go" || file.Name() == "options.go" {
 			os.Remove(file.Name())
 		}
 	}
@@ -67,10 +70,25 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if err := wrapTorOptions(); err != nil {
+		panic(err)
+	}
 	// Copy in the tor entrypoint wrappers, fill out the readme template
 	blob, _ = ioutil.ReadFile(filepath.Join("build", "libtor_internal.go.in"))
 	ioutil.WriteFile(filepath.Join("libtor", "libtor.go"), blob, 0644)
 
+	blob, _ = ioutil.ReadFile(filepath.Join("build", "libtor_log.go.in"))
+	ioutil.WriteFile(filepath.Join("libtor", "libtor_log.go"), blob, 0644)
+
+	blob, _ = ioutil.ReadFile(filepath.Join("build", "libtor_events.go.in"))
+	ioutil.WriteFile(filepath.Join("libtor", "libtor_events.go"), blob, 0644)
+
+	blob, _ = ioutil.ReadFile(filepath.Join("build", "libtor_paths.go.in"))
+	ioutil.WriteFile(filepath.Join("libtor", "libtor_paths.go"), blob, 0644)
+
+	blob, _ = ioutil.ReadFile(filepath.Join("build", "libtor_onion.go.in"))
+	ioutil.WriteFile(filepath.Join("libtor", "libtor_onion.go"), blob, 0644)
+
 	blob, _ = ioutil.ReadFile(filepath.Join("build", "libtor_external.go.in"))
 	ioutil.WriteFile("libtor.go", blob, 0644)
 
@@ -553,6 +571,24 @@ func wrapTor(nobuild bool) (string, string, error) {
 	}
 	commit = bytes.TrimSpace(commit)
 
+	// Apply any go-libtor specific fixes on top of the upstream sources
+	patches, err := filepath.Glob(filepath.Join("config", "tor", "patches", "*.patch"))
+	if err != nil {
+		return "", "", err
+	}
+	for _, patch := range patches {
+		abspatch, err := filepath.Abs(patch)
+		if err != nil {
+			return "", "", err
+		}
+		patcher := exec.Command("git", "apply", abspatch)
+		patcher.Dir = "tor"
+
+		if out, err := patcher.CombinedOutput(); err != nil {
+			fmt.Println(string(out))
+			return "", "", err
+		}
+	}
 	// Configure the library for compilation
 	autogen := exec.Command("./autogen.sh")
 	autogen.Dir = "tor"
@@ -736,3 +772,263 @@ package libtor
 */
 import "C"
 `
+
+// torOption is a single entry from tor's configuration option table.
+type torOption struct {
+	Name    string // Name of the option as accepted by tor
+	Type    string // Tor configuration type of the option (e.g. BOOL)
+	Default string // Default value of the option, empty if none
+}
+
+// torOptionTypes maps tor's configuration types to the Go types of the fields
+// representing them and the expressions to format a field's value with.
+var torOptionTypes = map[string]struct{ Go, Format string }{
+	"STRING":        {"string", "v"},
+	"FILENAME":      {"string", "v"},
+	"UINT":          {"*int", "strconv.Itoa(*v)"},
+	"INT":           {"*int", "strconv.Itoa(*v)"},
+	"UINT64":        {"*uint64", "strconv.FormatUint(*v, 10)"},
+	"PORT":          {"*int", "strconv.Itoa(*v)"},
+	"INTERVAL":      {"*time.Duration", "formatInterval(*v)"},
+	"MSEC_INTERVAL": {"*time.Duration", "formatMsecInterval(*v)"},
+	"MEMUNIT":       {"*uint64", "formatMemunit(*v)"},
+	"DOUBLE":        {"*float64", "strconv.FormatFloat(*v, 'f', -1, 64)"},
+	"BOOL":          {"*bool", "formatBool(*v)"},
+	"AUTOBOOL":      {"AutoBool", "string(v)"},
+	"CSV":           {"[]string", "strings.Join(v, \",\")"},
+	"CSV_INTERVAL":  {"*time.Duration", "formatInterval(*v)"}, // Only the first item is used by tor
+	"ROUTERSET":     {"[]string", "strings.Join(v, \",\")"},
+	"LINELIST":      {"[]string", ""},
+	"PORTLINES":     {"[]PortLine", ""},
+}
+
+// torManagedOptions are options that libtor sets up itself and thus must not be
+// exposed in the typed configuration.
+var torManagedOptions = map[string]bool{
+	"DataDirectory": true,
+}
+
+// wrapTorOptions generates a typed Go struct from tor's configuration option
+// table, along with the code to serialize it into tor command line arguments.
+//
+// Obsolete and internal options are skipped, as are the context sensitive hidden service option
+// blocks (dependent line lists), which cannot be represented as independent fields.
+func wrapTorOptions() error {
+	src, err := ioutil.ReadFile(filepath.Join("tor", "src", "app", "config", "config.c"))
+	if err != nil {
+		return err
+	}
+	opts, err := parseTorOptions(string(src))
+	if err != nil {
+		return err
+	}
+	var (
+		fields []torOption
+		names  []string
+	)
+	for _, opt := range opts {
+		if opt.Type != "OBSOLETE" {
+			names = append(names, opt.Name)
+		}
+		switch {
+		case opt.Type == "OBSOLETE" || opt.Type == "LINELIST_S" || opt.Type == "LINELIST_V":
+			continue
+		case strings.HasPrefix(opt.Name, "__"):
+			continue // Internal options, only settable via the control port
+		case torManagedOptions[opt.Name]:
+			continue
+		}
+		if _, ok := torOptionTypes[opt.Type]; !ok {
+			return fmt.Errorf("unknown type %s for option %s", opt.Type, opt.Name)
+		}
+		fields = append(fields, opt)
+	}
+	buf := new(bytes.Buffer)
+	if err := torOptionsTemplate.Execute(buf, struct {
+		Fields []torOption
+		Names  []string
+	}{fields, names}); err != nil {
+		return err
+	}
+	blob, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile("options.go", blob, 0644)
+}
+
+// parseTorOptions extracts the entries of the option_vars_ table from tor's
+// config.c source, keeping the non-Windows variants of platform specific ones.
+func parseTorOptions(src string) ([]torOption, error) {
+	start := strings.Index(src, "static config_var_t option_vars_[] = {")
+	if start < 0 {
+		return nil, errors.New("option table not found")
+	}
+	end := strings.Index(src[start:], "END_OF_CONFIG_VARS")
+	if end < 0 {
+		return nil, errors.New("option table end not found")
+	}
+	table := src[start+strings.Index(src[start:], "{")+1 : start+end]
+
+	// Strip out all the comments and Windows specific entries
+	table = regexp.MustCompile(`(?s)/\*.*?\*/`).ReplaceAllString(table, "")
+
+	var (
+		lines   []string
+		skipped bool
+	)
+	for _, line := range strings.Split(table, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#ifdef _WIN32"):
+			skipped = true
+		case strings.HasPrefix(trimmed, "#else"):
+			skipped = !skipped
+		case strings.HasPrefix(trimmed, "#endif"):
+			skipped = false
+		case strings.HasPrefix(trimmed, "#"):
+			return nil, fmt.Errorf("unsupported preprocessor directive: %s", trimmed)
+		case !skipped:
+			lines = append(lines, trimmed)
+		}
+	}
+	table = strings.Join(lines, " ")
+
+	// Iterate over the option entry macros and parse their arguments
+	var opts []torOption
+	for _, loc := range regexp.MustCompile(`\b(V|VAR|VPORT|OBSOLETE)\(`).FindAllStringSubmatchIndex(table, -1) {
+		args, err := splitMacroArgs(table[loc[1]:])
+		if err != nil {
+			return nil, err
+		}
+		switch macro := table[loc[2]:loc[3]]; macro {
+		case "V":
+			opts = append(opts, torOption{Name: args[0], Type: args[1], Default: parseDefault(args[2])})
+		case "VAR":
+			opts = append(opts, torOption{Name: parseDefault(args[0]), Type: args[1], Default: parseDefault(args[3])})
+		case "VPORT":
+			// Port options expand into a virtual and two dependent entries, of
+			// which only the one named after the port is meant to be set
+			opts = append(opts, torOption{Name: args[0], Type: "PORTLINES"})
+		case "OBSOLETE":
+			opts = append(opts, torOption{Name: parseDefault(args[0]), Type: "OBSOLETE"})
+		}
+	}
+	return opts, nil
+}
+
+// splitMacroArgs splits the top level, comma separated arguments of a C macro
+// invocation, starting right after its opening parenthesis.
+func splitMacroArgs(src string) ([]string, error) {
+	var (
+		args  []string
+		depth int
+		quote bool
+		last  int
+	)
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case quote && c == '\\':
+			i++
+		case c == '"':
+			quote = !quote
+		case quote:
+		case c == '(':
+			depth++
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(src[last:i]))
+			last = i + 1
+		case c == ')':
+			if depth == 0 {
+				return append(args, strings.TrimSpace(src[last:i])), nil
+			}
+			depth--
+		}
+	}
+	return nil, errors.New("unterminated macro invocation")
+}
+
+// parseDefault converts a C string expression into its value. Expressions built
+// from platform specific macros are reported as such.
+func parseDefault(expr string) string {
+	if expr == "NULL" {
+		return ""
+	}
+	var value string
+	for _, token := range regexp.MustCompile(`"(?:[^"\\]|\\.)*"|\S+`).FindAllString(expr, -1) {
+		if !strings.HasPrefix(token, "\"") {
+			return "platform dependent"
+		}
+		value += token[1 : len(token)-1]
+	}
+	return value
+}
+
+// torOptionsTemplate is the source file template of the generated tor options.
+var torOptionsTemplate = template.Must(template.New("").Funcs(template.FuncMap{
+	"gotype": func(kind string) string { return torOptionTypes[kind].Go },
+	"format": func(kind string) string { return torOptionTypes[kind].Format },
+	"lower":  strings.ToLower,
+}).Parse(`// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+// Code generated by build/wrap.go from tor's option table. DO NOT EDIT.
+
+package libtor
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options is the typed configuration of the embedded tor, generated from the
+// option table of the wrapped tor release. Unset (nil or empty) fields are left
+// at tor's defaults.
+//
+// Hidden service blocks are context sensitive and cannot be expressed as plain
+// fields; use raw arguments or the onion service API for those.
+type Options struct {
+{{- range .Fields}}
+	// {{.Name}} configures tor's {{.Name}} option{{if .Default}} (default: {{printf "%q" .Default}}){{end}}.
+	{{.Name}} {{gotype .Type}}
+{{- end}}
+}
+
+// Args serializes the configured options into tor command line arguments.
+func (o *Options) Args() []string {
+	var args []string
+{{- range .Fields}}
+	{{- if eq .Type "LINELIST"}}
+	for _, v := range o.{{.Name}} {
+		args = append(args, "--{{.Name}}", v)
+	}
+	{{- else if eq .Type "PORTLINES"}}
+	for _, v := range o.{{.Name}} {
+		args = append(args, "--{{.Name}}", v.String())
+	}
+	{{- else if eq (gotype .Type) "string" "AutoBool"}}
+	if v := o.{{.Name}}; v != "" {
+		args = append(args, "--{{.Name}}", {{format .Type}})
+	}
+	{{- else if eq (gotype .Type) "[]string"}}
+	if v := o.{{.Name}}; len(v) > 0 {
+		args = append(args, "--{{.Name}}", {{format .Type}})
+	}
+	{{- else}}
+	if v := o.{{.Name}}; v != nil {
+		args = append(args, "--{{.Name}}", {{format .Type}})
+	}
+	{{- end}}
+{{- end}}
+	return args
+}
+
+// optionNames maps the lowercased names of all options known by tor to their
+// canonical form.
+var optionNames = map[string]string{
+{{- range .Names}}
+	{{printf "%q" (lower .)}}: {{printf "%q" .}},
+{{- end}}
+}
+`))
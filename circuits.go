@@ -0,0 +1,115 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// circuitKey is the context key of the per-call circuit pins.
+type circuitKey struct{}
+
+// WithCircuit returns a context that makes the connections dialed with it go over
+// the given circuit, which needs to be open. If it closes before their streams
+// are attached, or detaches them, dialing fails.
+//
+// Pinning overrides both the isolation key and the exit constraints of a dial.
+func WithCircuit(ctx context.Context, circuit uint64) context.Context {
+	return context.WithValue(ctx, circuitKey{}, circuit)
+}
+
+// pinnedCircuit returns the circuit pin of a context, 0 if none is set.
+func pinnedCircuit(ctx context.Context) uint64 {
+	circuit, _ := ctx.Value(circuitKey{}).(uint64)
+	return circuit
+}
+
+// BuildCircuit builds a circuit through the given relays (fingerprints or
+// nicknames), the first one being the entry and the last one the exit, waiting
+// until it's built. If the context is cancelled meanwhile, the circuit is closed.
+//
+// The circuit has the controller purpose, so tor never attaches streams to it on
+// its own: connections can be sent over it via WithCircuit or SetStreamAttacher.
+// Tor closes it after MaxCircuitDirtiness once used and left without streams.
+func (t *Tor) BuildCircuit(ctx context.Context, path ...string) (uint64, error) {
+	if len(path) == 0 {
+		return 0, errors.New("empty circuit path")
+	}
+	for _, relay := range path {
+		if err := validateRouter(relay); err != nil {
+			return 0, err
+		}
+	}
+	return t.conns.build(ctx, "0 "+strings.Join(path, ",")+" purpose=controller", true)
+}
+
+// ExtendCircuit extends an open circuit through the given relays (fingerprints
+// or nicknames), waiting until it's built up to the last one.
+func (t *Tor) ExtendCircuit(ctx context.Context, circuit uint64, path ...string) error {
+	if len(path) == 0 {
+		return errors.New("empty circuit path")
+	}
+	for _, relay := range path {
+		if err := validateRouter(relay); err != nil {
+			return err
+		}
+	}
+	_, err := t.conns.build(ctx, strconv.FormatUint(circuit, 10)+" "+strings.Join(path, ","), false)
+	return err
+}
+
+// CloseCircuit closes a circuit, along with any streams attached to it.
+func (t *Tor) CloseCircuit(ctx context.Context, circuit uint64) error {
+	return t.ctrl.CloseCircuit(ctx, strconv.FormatUint(circuit, 10), false)
+}
+
+// SetStreamAttacher takes over attaching new streams from tor, calling attach to
+// choose the circuit of every stream not pinned via WithCircuit or to exit
+// constraints, including the ones of other SOCKS clients. The streams of failed
+// attempts tor detaches from their circuits are passed to it again.
+//
+// The attacher returns the circuit to attach the stream to, or 0 for tor to pick
+// one on its own. A stream whose chosen circuit isn't open gets closed. A nil
+// attacher hands all unpinned streams back to tor, which attaches new streams on
+// its own again once no connections are pinned to circuits.
+//
+// The attacher is called from a single goroutine in the order of the streams, so
+// it holds up attaching the others while it runs, but it may issue control
+// commands.
+func (t *Tor) SetStreamAttacher(attach func(stream *StreamEvent) uint64) error {
+	t.conns.lock.Lock()
+	t.conns.chooser = attach
+	t.conns.lock.Unlock()
+
+	if attach == nil {
+		return t.conns.giveBack()
+	}
+	return t.conns.takeOver()
+}
+
+// build launches or extends a circuit with the given EXTENDCIRCUIT arguments,
+// waiting for tor to build it. If the context is cancelled meanwhile, a launched
+// circuit is closed.
+func (t *connTracker) build(ctx context.Context, args string, launched bool) (uint64, error) {
+	build := &circuitBuild{done: make(chan struct{})}
+	if err := t.launch(build, args); err != nil {
+		return 0, err
+	}
+	select {
+	case <-build.done:
+		return build.id, build.err
+	case <-ctx.Done():
+		t.lock.Lock()
+		delete(t.launches, build.id)
+		t.lock.Unlock()
+
+		if launched {
+			go t.closeCircuit(build.id)
+		}
+		return 0, ctx.Err()
+	}
+}
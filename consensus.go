@@ -0,0 +1,385 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipsn/go-libtor/control"
+)
+
+// ErrNoConsensus is returned when querying the consensus of a tor that has none
+// usable yet.
+var ErrNoConsensus = errors.New("no consensus available")
+
+// consensusTimeLayout is the format of the times in the consensus documents.
+const consensusTimeLayout = "2006-01-02 15:04:05"
+
+// Consensus is the embedded tor's current view of the network: the consensus it
+// uses, with the microdescriptors it has of the listed relays.
+type Consensus struct {
+	ValidAfter time.Time // Time the consensus became valid at
+	FreshUntil time.Time // Time the next consensus is expected by
+	ValidUntil time.Time // Time the consensus stops being usable at
+
+	Params           map[string]int // Network parameters voted by the authorities
+	BandwidthWeights map[string]int // Weights of the relay positions in path selection (e.g. Wgg, Wee)
+
+	Relays []*RelayStatus // Relays listed in the consensus, ordered by fingerprint
+}
+
+// RelayStatus is the status of a relay listed in the consensus.
+type RelayStatus struct {
+	Relay
+
+	Published  time.Time  // Publication time of the relay's descriptor
+	Address    string     // IPv4 address of the relay
+	ORPort     int        // Port of the relay's onion router
+	DirPort    int        // Port of the relay's directory, 0 if none
+	IPv6       []string   // IPv6 addresses and ports of the onion router, as "[address]:port"
+	Flags      []string   // Flags assigned by the authorities (e.g. Exit, Fast, Guard, Stable)
+	Bandwidth  int        // Consensus weight of the relay, in kilobytes per second
+	ExitPolicy string     // Summary of the IPv4 exit policy (e.g. "accept 80,443"), empty if unknown
+	Microdesc  *Microdesc // Microdescriptor of the relay, nil if tor hasn't got it (yet)
+}
+
+// Microdesc is the microdescriptor of a relay, carrying what's needed to build
+// circuits through it.
+type Microdesc struct {
+	Digest      string   // Base64 encoded SHA256 digest of the microdescriptor
+	NtorKey     string   // Base64 encoded curve25519 onion key
+	Ed25519     string   // Base64 encoded ed25519 identity key, empty if none
+	Family      []string // Relays the operator declared as its family, "$fingerprint" or nickname
+	ExitPolicy  string   // Summary of the IPv4 exit policy, empty if not an exit
+	ExitPolicy6 string   // Summary of the IPv6 exit policy, empty if not an exit
+}
+
+// Consensus returns the consensus the embedded tor currently uses, or fails with
+// ErrNoConsensus if it has none yet. Subscribe to EventNewConsensus to be notified
+// when tor switches to a newer one.
+func (t *Tor) Consensus(ctx context.Context) (*Consensus, error) {
+	infos, err := t.ctrl.GetInfo(ctx, "consensus/valid-after", "consensus/fresh-until",
+		"consensus/valid-until", "consensus/params", "consensus/bandwidth-weights", "consensus/relays")
+	if err != nil {
+		if cerr, ok := err.(*control.Error); ok && cerr.Code == 551 && cerr.Message == "No consensus available" {
+			return nil, ErrNoConsensus
+		}
+		return nil, err
+	}
+	consensus := new(Consensus)
+	for key, ts := range map[string]*time.Time{
+		"consensus/valid-after": &consensus.ValidAfter,
+		"consensus/fresh-until": &consensus.FreshUntil,
+		"consensus/valid-until": &consensus.ValidUntil,
+	} {
+		if *ts, err = time.Parse(consensusTimeLayout, infos[key]); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+	if consensus.Params, err = parseConsensusParams(infos["consensus/params"]); err != nil {
+		return nil, err
+	}
+	if consensus.BandwidthWeights, err = parseConsensusParams(infos["consensus/bandwidth-weights"]); err != nil {
+		return nil, err
+	}
+	if consensus.Relays, err = parseRelayStatuses(infos["consensus/relays"]); err != nil {
+		return nil, err
+	}
+	return consensus, nil
+}
+
+// Relay returns the relay with the given fingerprint (with or without a leading
+// '$') or nickname, nil if the consensus doesn't list it.
+func (c *Consensus) Relay(relay string) *RelayStatus {
+	for _, status := range c.Relays {
+		if status.matches(relay) {
+			return status
+		}
+	}
+	return nil
+}
+
+// RelayFilter reports whether a relay is of interest.
+type RelayFilter func(relay *RelayStatus) bool
+
+// Filter returns the relays matching all the given filters.
+func (c *Consensus) Filter(filters ...RelayFilter) []*RelayStatus {
+	var relays []*RelayStatus
+	for _, relay := range c.Relays {
+		matches := true
+		for _, filter := range filters {
+			if !filter(relay) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			relays = append(relays, relay)
+		}
+	}
+	return relays
+}
+
+// Family returns the relays in the same family as the given one, those declaring
+// each other as family members. The relay itself is not included.
+func (c *Consensus) Family(relay *RelayStatus) []*RelayStatus {
+	if relay.Microdesc == nil {
+		return nil
+	}
+	var family []*RelayStatus
+	for _, member := range relay.Microdesc.Family {
+		other := c.Relay(member)
+		if other == nil || other == relay || other.Microdesc == nil || containsRelay(family, other) {
+			continue
+		}
+		for _, back := range other.Microdesc.Family {
+			if relay.matches(back) {
+				family = append(family, other)
+				break
+			}
+		}
+	}
+	return family
+}
+
+// WithFlags filters for the relays having all the given flags.
+func WithFlags(flags ...string) RelayFilter {
+	return func(relay *RelayStatus) bool {
+		for _, flag := range flags {
+			if !relay.HasFlag(flag) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// WithMinBandwidth filters for the relays with at least the given consensus
+// weight, in kilobytes per second.
+func WithMinBandwidth(bandwidth int) RelayFilter {
+	return func(relay *RelayStatus) bool {
+		return relay.Bandwidth >= bandwidth
+	}
+}
+
+// WithExitPort filters for the relays usable as exits to the given IPv4 port.
+func WithExitPort(port int) RelayFilter {
+	return func(relay *RelayStatus) bool {
+		return relay.HasFlag("Exit") && !relay.HasFlag("BadExit") && relay.AllowsExit(port)
+	}
+}
+
+// HasFlag reports whether the authorities assigned the given flag to the relay.
+func (r *RelayStatus) HasFlag(flag string) bool {
+	for _, have := range r.Flags {
+		if have == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsExit reports whether the relay's exit policy summary accepts connections
+// to the given IPv4 port, false if the policy is unknown.
+func (r *RelayStatus) AllowsExit(port int) bool {
+	policy := r.ExitPolicy
+	if r.Microdesc != nil {
+		policy = r.Microdesc.ExitPolicy
+	}
+	parts := strings.Fields(policy)
+	if len(parts) != 2 || (parts[0] != "accept" && parts[0] != "reject") {
+		return false
+	}
+	listed := false
+	for _, item := range strings.Split(parts[1], ",") {
+		low, high := item, item
+		if idx := strings.IndexByte(item, '-'); idx >= 0 {
+			low, high = item[:idx], item[idx+1:]
+		}
+		lo, err1 := strconv.Atoi(low)
+		hi, err2 := strconv.Atoi(high)
+		if err1 == nil && err2 == nil && lo <= port && port <= hi {
+			listed = true
+			break
+		}
+	}
+	return listed == (parts[0] == "accept")
+}
+
+// matches reports whether the relay is the one named by a fingerprint (with or
+// without a leading '$', optionally followed by its nickname) or a nickname.
+func (r *RelayStatus) matches(name string) bool {
+	if strings.HasPrefix(name, "$") {
+		return strings.EqualFold(parseRelay(name).Fingerprint, r.Fingerprint)
+	}
+	if len(name) == 2*20 && strings.EqualFold(name, r.Fingerprint) {
+		return true
+	}
+	return strings.EqualFold(name, r.Nickname)
+}
+
+// parseConsensusParams parses the space separated "key=value" integer parameters
+// of the consensus.
+func parseConsensusParams(text string) (map[string]int, error) {
+	params := make(map[string]int)
+	for _, param := range strings.Fields(text) {
+		idx := strings.IndexByte(param, '=')
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid consensus parameter: %q", param)
+		}
+		value, err := strconv.Atoi(param[idx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid consensus parameter: %q", param)
+		}
+		params[param[:idx]] = value
+	}
+	return params, nil
+}
+
+// parseRelayStatuses parses the relays reported by tor, each the router status
+// entry in control port format, optionally followed by the "m" line with the
+// digest of its microdescriptor and the microdescriptor itself.
+func parseRelayStatuses(text string) ([]*RelayStatus, error) {
+	var (
+		relays []*RelayStatus
+		relay  *RelayStatus
+		pem    bool // Whether the lines of a PEM encoded key are being skipped
+	)
+	for _, line := range strings.Split(text, "\n") {
+		if pem {
+			pem = !strings.HasPrefix(line, "-----END")
+			continue
+		}
+		if strings.HasPrefix(line, "-----BEGIN") {
+			pem = true
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "r" {
+			status, err := parseRouterLine(fields)
+			if err != nil {
+				return nil, err
+			}
+			relay = status
+			relays = append(relays, relay)
+			continue
+		}
+		if relay == nil {
+			return nil, fmt.Errorf("relay status without router line: %q", line)
+		}
+		// Lines following the "m" one belong to the microdescriptor
+		if md := relay.Microdesc; md != nil {
+			switch fields[0] {
+			case "ntor-onion-key":
+				if len(fields) > 1 {
+					md.NtorKey = fields[1]
+				}
+			case "a":
+				if len(fields) > 1 && !containsString(relay.IPv6, fields[1]) {
+					relay.IPv6 = append(relay.IPv6, fields[1])
+				}
+			case "family":
+				md.Family = fields[1:]
+			case "p":
+				md.ExitPolicy = strings.Join(fields[1:], " ")
+			case "p6":
+				md.ExitPolicy6 = strings.Join(fields[1:], " ")
+			case "id":
+				if len(fields) > 2 && fields[1] == "ed25519" {
+					md.Ed25519 = fields[2]
+				}
+			}
+			continue
+		}
+		switch fields[0] {
+		case "a":
+			if len(fields) > 1 {
+				relay.IPv6 = append(relay.IPv6, fields[1])
+			}
+		case "s":
+			relay.Flags = fields[1:]
+		case "w":
+			for _, field := range fields[1:] {
+				if strings.HasPrefix(field, "Bandwidth=") {
+					bandwidth, err := strconv.Atoi(strings.TrimPrefix(field, "Bandwidth="))
+					if err != nil {
+						return nil, fmt.Errorf("invalid relay bandwidth: %q", line)
+					}
+					relay.Bandwidth = bandwidth
+				}
+			}
+		case "p":
+			relay.ExitPolicy = strings.Join(fields[1:], " ")
+		case "m":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid microdescriptor digest: %q", line)
+			}
+			relay.Microdesc = &Microdesc{Digest: fields[1]}
+		}
+	}
+	return relays, nil
+}
+
+// parseRouterLine parses the "r" line starting a router status entry.
+func parseRouterLine(fields []string) (*RelayStatus, error) {
+	if len(fields) != 9 {
+		return nil, fmt.Errorf("invalid router line: %q", strings.Join(fields, " "))
+	}
+	identity, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(fields[2], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid relay identity: %q", fields[2])
+	}
+	published, err := time.Parse(consensusTimeLayout, fields[4]+" "+fields[5])
+	if err != nil {
+		return nil, fmt.Errorf("invalid relay publication time: %v", err)
+	}
+	orport, err := strconv.Atoi(fields[7])
+	if err != nil {
+		return nil, fmt.Errorf("invalid relay ORPort: %q", fields[7])
+	}
+	dirport, err := strconv.Atoi(fields[8])
+	if err != nil {
+		return nil, fmt.Errorf("invalid relay DirPort: %q", fields[8])
+	}
+	return &RelayStatus{
+		Relay: Relay{
+			Fingerprint: strings.ToUpper(hex.EncodeToString(identity)),
+			Nickname:    fields[1],
+		},
+		Published: published,
+		Address:   fields[6],
+		ORPort:    orport,
+		DirPort:   dirport,
+	}, nil
+}
+
+// containsString reports whether a string is among the items.
+func containsString(items []string, item string) bool {
+	for _, have := range items {
+		if have == item {
+			return true
+		}
+	}
+	return false
+}
+
+// containsRelay reports whether a relay is among the items.
+func containsRelay(items []*RelayStatus, item *RelayStatus) bool {
+	for _, have := range items {
+		if have == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipsn/go-libtor/control"
+)
+
+// Tests that querying the consensus before tor has one fails cleanly instead of
+// crashing tor.
+func TestConsensusBeforeBootstrap(t *testing.T) {
+	tor, err := Start(context.Background(), &Config{Args: []string{"--DisableNetwork", "1"}})
+	if err != nil {
+		t.Fatalf("failed to start tor: %v", err)
+	}
+	defer tor.Close()
+
+	if _, err := tor.Consensus(context.Background()); err != ErrNoConsensus {
+		t.Errorf("consensus error mismatch: have %v, want %v", err, ErrNoConsensus)
+	}
+	for _, key := range []string{"consensus/params", "consensus/bandwidth-weights", "consensus/relays", "consensus/valid-after"} {
+		_, err := tor.Control().GetInfo(context.Background(), key)
+		if cerr, ok := err.(*control.Error); !ok || cerr.Code != 551 {
+			t.Errorf("%s: error mismatch: have %v, want 551", key, err)
+		}
+	}
+	// The relay status queries answer empty instead
+	infos, err := tor.Control().GetInfo(context.Background(), "ns/all")
+	if err != nil {
+		t.Fatalf("failed to query relay statuses: %v", err)
+	}
+	if infos["ns/all"] != "" {
+		t.Errorf("relay statuses without consensus: %q", infos["ns/all"])
+	}
+}
@@ -0,0 +1,104 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"strings"
+)
+
+// ParseArguments splits the text of a reply or event line into its positional
+// arguments and its keyword (KEY=VALUE) arguments. Quoted values are unescaped.
+func ParseArguments(text string) ([]string, map[string]string) {
+	var (
+		positional []string
+		keywords   = make(map[string]string)
+	)
+	for text != "" {
+		// Skip any whitespace before the next argument
+		text = strings.TrimLeft(text, " ")
+		if text == "" {
+			break
+		}
+		// Quoted positional arguments are returned unquoted
+		if text[0] == '"' {
+			value, rest := unquote(text)
+			positional, text = append(positional, value), rest
+			continue
+		}
+		// Split the next argument and check whether it's a keyword one
+		end := strings.IndexAny(text, " =")
+		if end < 0 || text[end] == ' ' {
+			if end < 0 {
+				end = len(text)
+			}
+			positional, text = append(positional, text[:end]), text[end:]
+			continue
+		}
+		key := text[:end]
+		text = text[end+1:]
+
+		if strings.HasPrefix(text, "\"") {
+			keywords[key], text = unquote(text)
+			continue
+		}
+		if end = strings.IndexByte(text, ' '); end < 0 {
+			end = len(text)
+		}
+		keywords[key], text = text[:end], text[end:]
+	}
+	return positional, keywords
+}
+
+// unquote parses a quoted string from the start of the text, returning its value
+// and the remainder of the text after it. Unterminated strings run until the end.
+func unquote(text string) (string, string) {
+	var value strings.Builder
+	for i := 1; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '"':
+			return value.String(), text[i+1:]
+
+		case '\\':
+			if i++; i == len(text) {
+				return value.String(), ""
+			}
+			switch text[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			default:
+				value.WriteByte(text[i])
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return value.String(), ""
+}
+
+// Quote encodes a string as a control protocol quoted string.
+func Quote(s string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(c)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		default:
+			quoted.WriteByte(c)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
@@ -0,0 +1,142 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// ProtocolInfo is the information tor discloses to controllers before they are
+// authenticated.
+type ProtocolInfo struct {
+	AuthMethods []string // Authentication methods accepted (e.g. NULL, HASHEDPASSWORD, COOKIE, SAFECOOKIE)
+	CookieFile  string   // Path of the authentication cookie, if cookie authentication is enabled
+	TorVersion  string   // Version of tor running
+}
+
+// HasAuthMethod returns whether tor accepts the given authentication method.
+func (p *ProtocolInfo) HasAuthMethod(method string) bool {
+	for _, m := range p.AuthMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProtocolInfo retrieves the protocol information from tor. Unauthenticated
+// connections may only issue it once, before authenticating.
+func (c *Conn) ProtocolInfo(ctx context.Context) (*ProtocolInfo, error) {
+	res, err := c.Request(ctx, "PROTOCOLINFO 1")
+	if err != nil {
+		return nil, err
+	}
+	info := new(ProtocolInfo)
+	for _, line := range res.Lines {
+		args, keywords := ParseArguments(line.Text)
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "AUTH":
+			if methods := keywords["METHODS"]; methods != "" {
+				info.AuthMethods = strings.Split(methods, ",")
+			}
+			info.CookieFile = keywords["COOKIEFILE"]
+		case "VERSION":
+			info.TorVersion = keywords["Tor"]
+		}
+	}
+	return info, nil
+}
+
+// Authentication hash keys of the SAFECOOKIE method.
+const (
+	safeCookieServerKey = "Tor safe cookie authentication server-to-controller hash"
+	safeCookieClientKey = "Tor safe cookie authentication controller-to-server hash"
+)
+
+// Authenticate authenticates the connection with the strongest method tor accepts
+// for which the credentials are available: no authentication, the password if not
+// empty, or the cookie file. Connections not authenticated are closed by tor on
+// any other command.
+//
+// The owning control connection of an embedded tor is authenticated already.
+func (c *Conn) Authenticate(ctx context.Context, password string) error {
+	info, err := c.ProtocolInfo(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.HasAuthMethod("NULL"):
+		_, err = c.Request(ctx, "AUTHENTICATE")
+		return err
+
+	case info.HasAuthMethod("HASHEDPASSWORD") && password != "":
+		_, err = c.Request(ctx, "AUTHENTICATE %s", Quote(password))
+		return err
+
+	case info.HasAuthMethod("SAFECOOKIE") && info.CookieFile != "":
+		return c.authenticateSafeCookie(ctx, info.CookieFile)
+
+	case info.HasAuthMethod("COOKIE") && info.CookieFile != "":
+		cookie, err := ioutil.ReadFile(info.CookieFile)
+		if err != nil {
+			return fmt.Errorf("failed to read auth cookie: %v", err)
+		}
+		_, err = c.Request(ctx, "AUTHENTICATE %s", hex.EncodeToString(cookie))
+		return err
+	}
+	return fmt.Errorf("no usable authentication method: %s", strings.Join(info.AuthMethods, ","))
+}
+
+// authenticateSafeCookie runs the SAFECOOKIE challenge-response authentication,
+// proving the knowledge of the cookie without disclosing it to the other side.
+func (c *Conn) authenticateSafeCookie(ctx context.Context, path string) error {
+	cookie, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read auth cookie: %v", err)
+	}
+	clientNonce := make([]byte, 32)
+	if _, err := rand.Read(clientNonce); err != nil {
+		return err
+	}
+	res, err := c.Request(ctx, "AUTHCHALLENGE SAFECOOKIE %s", hex.EncodeToString(clientNonce))
+	if err != nil {
+		return err
+	}
+	args, keywords := ParseArguments(res.Text())
+	if len(args) == 0 || args[0] != "AUTHCHALLENGE" {
+		return fmt.Errorf("malformed auth challenge: %s", res.Text())
+	}
+	serverHash, err := hex.DecodeString(keywords["SERVERHASH"])
+	if err != nil {
+		return fmt.Errorf("malformed auth challenge server hash: %v", err)
+	}
+	serverNonce, err := hex.DecodeString(keywords["SERVERNONCE"])
+	if err != nil {
+		return fmt.Errorf("malformed auth challenge server nonce: %v", err)
+	}
+	// Ensure tor knows the cookie too before sending our proof over
+	message := append(append(append([]byte{}, cookie...), clientNonce...), serverNonce...)
+
+	mac := hmac.New(sha256.New, []byte(safeCookieServerKey))
+	mac.Write(message)
+	if !hmac.Equal(mac.Sum(nil), serverHash) {
+		return errors.New("auth challenge server hash mismatch")
+	}
+	mac = hmac.New(sha256.New, []byte(safeCookieClientKey))
+	mac.Write(message)
+
+	_, err = c.Request(ctx, "AUTHENTICATE %s", hex.EncodeToString(mac.Sum(nil)))
+	return err
+}
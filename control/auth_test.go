@@ -0,0 +1,19 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"testing"
+)
+
+// Tests that connections are authenticated without credentials if tor allows.
+func TestAuthenticateNull(t *testing.T) {
+	conn, done := replay(t, "auth.txt")
+	defer done()
+
+	if err := conn.Authenticate(context.Background(), ""); err != nil {
+		t.Errorf("failed to authenticate: %v", err)
+	}
+}
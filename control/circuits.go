@@ -0,0 +1,82 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ExtendCircuit builds a new circuit through the given relays (fingerprints or
+// nicknames), or extends an existing one if circID is not empty or "0". With no
+// path, tor picks one itself. The purpose may be "general", "controller" or
+// empty for the default. The identifier of the circuit is returned.
+func (c *Conn) ExtendCircuit(ctx context.Context, circID string, path []string, purpose string) (string, error) {
+	if circID == "" {
+		circID = "0"
+	}
+	line := "EXTENDCIRCUIT " + circID
+	if len(path) > 0 {
+		line += " " + strings.Join(path, ",")
+	}
+	if purpose != "" {
+		line += " purpose=" + purpose
+	}
+	res, err := c.Request(ctx, "%s", line)
+	if err != nil {
+		return "", err
+	}
+	args, _ := ParseArguments(res.Text())
+	if len(args) != 2 || args[0] != "EXTENDED" {
+		return "", fmt.Errorf("malformed circuit extension reply: %s", res.Text())
+	}
+	return args[1], nil
+}
+
+// CloseCircuit closes a circuit, optionally only once it has no more streams.
+func (c *Conn) CloseCircuit(ctx context.Context, circID string, ifUnused bool) error {
+	line := "CLOSECIRCUIT " + circID
+	if ifUnused {
+		line += " IfUnused"
+	}
+	_, err := c.Request(ctx, "%s", line)
+	return err
+}
+
+// SetCircuitPurpose changes the purpose of a circuit ("general" or "controller").
+func (c *Conn) SetCircuitPurpose(ctx context.Context, circID string, purpose string) error {
+	_, err := c.Request(ctx, "SETCIRCUITPURPOSE %s purpose=%s", circID, purpose)
+	return err
+}
+
+// AttachStream attaches a stream to a circuit, exiting at the given hop of it
+// (0 for the last one). A circID of "0" hands the stream back to tor to attach
+// it on its own.
+func (c *Conn) AttachStream(ctx context.Context, streamID string, circID string, hop int) error {
+	line := "ATTACHSTREAM " + streamID + " " + circID
+	if hop > 0 {
+		line += fmt.Sprintf(" HOP=%d", hop)
+	}
+	_, err := c.Request(ctx, "%s", line)
+	return err
+}
+
+// RedirectStream changes the target address (and port, if not 0) of a stream
+// that is not attached yet.
+func (c *Conn) RedirectStream(ctx context.Context, streamID string, address string, port int) error {
+	line := "REDIRECTSTREAM " + streamID + " " + address
+	if port > 0 {
+		line += fmt.Sprintf(" %d", port)
+	}
+	_, err := c.Request(ctx, "%s", line)
+	return err
+}
+
+// CloseStream closes a stream, sending the given END reason code to the exit
+// (e.g. 1 for a misc reason).
+func (c *Conn) CloseStream(ctx context.Context, streamID string, reason int) error {
+	_, err := c.Request(ctx, "CLOSESTREAM %s %d", streamID, reason)
+	return err
+}
@@ -0,0 +1,20 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"testing"
+)
+
+// Tests that circuit build failures are surfaced as control errors.
+func TestExtendCircuitFailure(t *testing.T) {
+	conn, done := replay(t, "circuits.txt")
+	defer done()
+
+	_, err := conn.ExtendCircuit(context.Background(), "", nil, "")
+	if cerr, ok := err.(*Error); !ok || cerr.Code != 551 || cerr.Message != "Couldn't start circuit" {
+		t.Errorf("circuit error mismatch: have %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GetInfo retrieves the values of the requested information keys from tor.
+func (c *Conn) GetInfo(ctx context.Context, keys ...string) (map[string]string, error) {
+	res, err := c.Request(ctx, "GETINFO %s", strings.Join(keys, " "))
+	if err != nil {
+		return nil, err
+	}
+	infos := make(map[string]string)
+	for _, pair := range res.keyValues() {
+		infos[pair[0]] = pair[1]
+	}
+	return infos, nil
+}
+
+// GetConf retrieves the values of the requested configuration options from tor.
+// Options accepting multiple lines may have multiple values, options without a
+// value (unset or at their default) have none.
+//
+// The returned keys are tor's canonical names of the options.
+func (c *Conn) GetConf(ctx context.Context, keys ...string) (map[string][]string, error) {
+	res, err := c.Request(ctx, "GETCONF %s", strings.Join(keys, " "))
+	if err != nil {
+		return nil, err
+	}
+	conf := make(map[string][]string)
+	for _, line := range res.Lines {
+		if idx := strings.IndexByte(line.Text, '='); idx >= 0 {
+			key, value := line.Text[:idx], line.Text[idx+1:]
+			if strings.HasPrefix(value, "\"") {
+				value, _ = unquote(value)
+			}
+			conf[key] = append(conf[key], value)
+			continue
+		}
+		if _, ok := conf[line.Text]; !ok {
+			conf[line.Text] = nil
+		}
+	}
+	return conf, nil
+}
+
+// ConfEntry is a configuration option and a value to set it to. Options accepting
+// multiple lines can be set to multiple values by repeating their entries.
+type ConfEntry struct {
+	Key   string
+	Value string
+}
+
+// SetConf changes the values of configuration options. All the given options are
+// set at once, and options not listed keep their values. If tor rejects any of
+// them, none of the changes are applied.
+func (c *Conn) SetConf(ctx context.Context, entries ...ConfEntry) error {
+	return c.changeConf(ctx, "SETCONF", entries)
+}
+
+// ResetConf resets configuration options to their default values, or changes
+// them similarly to SetConf, except that options listed with multiple values
+// have their earlier values dropped.
+func (c *Conn) ResetConf(ctx context.Context, entries ...ConfEntry) error {
+	return c.changeConf(ctx, "RESETCONF", entries)
+}
+
+// ResetConfDefaults resets configuration options to their default values.
+func (c *Conn) ResetConfDefaults(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return errors.New("no options to reset")
+	}
+	_, err := c.Request(ctx, "RESETCONF %s", strings.Join(keys, " "))
+	return err
+}
+
+// changeConf issues a SETCONF or RESETCONF command with the given entries.
+func (c *Conn) changeConf(ctx context.Context, command string, entries []ConfEntry) error {
+	if len(entries) == 0 {
+		return errors.New("no options to change")
+	}
+	args := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !isKeyword(entry.Key) {
+			return fmt.Errorf("invalid option name: %q", entry.Key)
+		}
+		args = append(args, entry.Key+"="+Quote(entry.Value))
+	}
+	_, err := c.Request(ctx, "%s %s", command, strings.Join(args, " "))
+	return err
+}
+
+// SaveConf requests tor to write its current configuration into its torrc, or
+// into the default torrc location if tor was started without one. It fails for
+// tors running with an in-memory torrc, which has no file to write back to.
+func (c *Conn) SaveConf(ctx context.Context) error {
+	_, err := c.Request(ctx, "SAVECONF")
+	return err
+}
+
+// Signal sends a signal (e.g. "NEWNYM", "RELOAD" or "SHUTDOWN") to tor.
+func (c *Conn) Signal(ctx context.Context, signal string) error {
+	_, err := c.Request(ctx, "SIGNAL %s", signal)
+	return err
+}
+
+// MapAddress requests tor to rewrite connections to the keys of the mappings to
+// the corresponding values. Keys of ".", "0.0.0.0" or "::0" ask tor to pick an
+// unused virtual address. The mappings established are returned.
+func (c *Conn) MapAddress(ctx context.Context, mappings map[string]string) (map[string]string, error) {
+	if len(mappings) == 0 {
+		return nil, errors.New("no addresses to map")
+	}
+	// Sort the mappings to keep the requests deterministic
+	args := make([]string, 0, len(mappings))
+	for from, to := range mappings {
+		if strings.ContainsAny(from, " =\"") || strings.ContainsAny(to, " =\"") {
+			return nil, fmt.Errorf("invalid address mapping: %s=%s", from, to)
+		}
+		args = append(args, from+"="+to)
+	}
+	sort.Strings(args)
+
+	res, err := c.Request(ctx, "MAPADDRESS %s", strings.Join(args, " "))
+	if err != nil {
+		return nil, err
+	}
+	mapped := make(map[string]string)
+	for _, pair := range res.keyValues() {
+		mapped[pair[0]] = pair[1]
+	}
+	return mapped, nil
+}
+
+// Resolve requests tor to resolve a hostname (or an address if reverse is set)
+// over the Tor network, waiting for the answer. Answers are cached by tor, and
+// also delivered to the subscribers of ADDRMAP events.
+func (c *Conn) Resolve(ctx context.Context, address string, reverse bool) (string, error) {
+	if address == "" || strings.ContainsAny(address, " \"") {
+		return "", fmt.Errorf("invalid address to resolve: %q", address)
+	}
+	// Subscribe to address mappings before requesting the resolution
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan *Event, 1)
+	sub, err := c.Subscribe(ctx, events, "ADDRMAP")
+	if err != nil {
+		return "", err
+	}
+	defer sub.Close()
+
+	mode := ""
+	if reverse {
+		mode = "mode=reverse "
+	}
+	if _, err := c.Request(ctx, "RESOLVE %s%s", mode, address); err != nil {
+		return "", err
+	}
+	for {
+		select {
+		case event := <-events:
+			args, _ := event.Arguments()
+			if len(args) < 2 || !strings.EqualFold(args[0], address) {
+				continue
+			}
+			if args[1] == "<error>" {
+				return "", &ResolveError{Address: address}
+			}
+			return args[1], nil
+
+		case <-ctx.Done():
+			return "", ctx.Err()
+
+		case <-c.closed:
+			return "", ErrClosed
+		}
+	}
+}
+
+// ResolveError is returned if tor fails to resolve an address.
+type ResolveError struct {
+	Address string // Address that failed to resolve
+}
+
+// Error implements error, formatting the resolution failure.
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("failed to resolve %s", e.Address)
+}
+
+// isKeyword returns whether a string is a valid control protocol keyword.
+func isKeyword(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '/' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// Tests that GETINFO replies are parsed, including multi-line data values,
+// empty values and failures.
+func TestGetInfo(t *testing.T) {
+	conn, done := replay(t, "getinfo.txt")
+	defer done()
+
+	ctx := context.Background()
+	tests := []struct {
+		keys []string
+		want map[string]string
+	}{
+		{
+			keys: []string{"version", "config-file"},
+			want: map[string]string{
+				"version":     "0.3.5.14-dev (git-da728e36f4579907)",
+				"config-file": "/torrc",
+			},
+		},
+		{
+			keys: []string{"config-text"},
+			want: map[string]string{
+				"config-text": "ControlPort auto\nDataDirectory /var/lib/tor\nDisableNetwork 1\nLog notice stdout\nSocksPort 0",
+			},
+		},
+		{
+			keys: []string{"net/listeners/socks"},
+			want: map[string]string{"net/listeners/socks": ""},
+		},
+	}
+	for i, tt := range tests {
+		have, err := conn.GetInfo(ctx, tt.keys...)
+		if err != nil {
+			t.Fatalf("test %d: failed to get info: %v", i, err)
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: info mismatch: have %q, want %q", i, have, tt.want)
+		}
+	}
+	_, err := conn.GetInfo(ctx, "no-such-key")
+	if cerr, ok := err.(*Error); !ok || cerr.Code != 552 || cerr.Message != `Unrecognized key "no-such-key"` {
+		t.Errorf("unknown key error mismatch: have %v", err)
+	}
+}
+
+// Tests that configuration values are quoted when set and unquoted when read
+// back, and that unset options are reported without values.
+func TestGetSetConf(t *testing.T) {
+	conn, done := replay(t, "getconf.txt")
+	defer done()
+
+	ctx := context.Background()
+	if err := conn.SetConf(ctx, ConfEntry{"ContactInfo", "admin # ops"}, ConfEntry{"Nickname", "libtor"}); err != nil {
+		t.Fatalf("failed to set conf: %v", err)
+	}
+	have, err := conn.GetConf(ctx, "ContactInfo", "Nickname", "SocksPort", "Log", "ExitPolicy")
+	if err != nil {
+		t.Fatalf("failed to get conf: %v", err)
+	}
+	want := map[string][]string{
+		"ContactInfo": {"admin # ops"},
+		"Nickname":    {"libtor"},
+		"SocksPort":   {"0"},
+		"Log":         {"notice stdout"},
+		"ExitPolicy":  nil,
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("conf mismatch: have %q, want %q", have, want)
+	}
+	if err := conn.SetConf(ctx, ConfEntry{"ContactInfo", `"quoted" admin`}); err != nil {
+		t.Fatalf("failed to set conf: %v", err)
+	}
+	if have, err = conn.GetConf(ctx, "ContactInfo"); err != nil {
+		t.Fatalf("failed to get conf: %v", err)
+	}
+	if want := []string{`"quoted" admin`}; !reflect.DeepEqual(have["ContactInfo"], want) {
+		t.Errorf("quoted conf mismatch: have %q, want %q", have["ContactInfo"], want)
+	}
+	err = conn.SetConf(ctx, ConfEntry{"NoSuchOption", "1"})
+	if cerr, ok := err.(*Error); !ok || cerr.Code != 552 {
+		t.Errorf("unknown option error mismatch: have %v", err)
+	}
+	if err := conn.ResetConfDefaults(ctx, "ContactInfo", "Nickname"); err != nil {
+		t.Fatalf("failed to reset conf: %v", err)
+	}
+	if have, err = conn.GetConf(ctx, "ContactInfo", "Nickname"); err != nil {
+		t.Fatalf("failed to get conf: %v", err)
+	}
+	if want := map[string][]string{"ContactInfo": nil, "Nickname": nil}; !reflect.DeepEqual(have, want) {
+		t.Errorf("reset conf mismatch: have %q, want %q", have, want)
+	}
+}
+
+// Tests that address mappings are requested in a deterministic order and the
+// virtual addresses picked by tor are returned.
+func TestMapAddress(t *testing.T) {
+	conn, done := replay(t, "mapaddress.txt")
+	defer done()
+
+	have, err := conn.MapAddress(context.Background(), map[string]string{
+		"1.2.3.4": "torproject.org",
+		"0.0.0.0": "example.com",
+	})
+	if err != nil {
+		t.Fatalf("failed to map addresses: %v", err)
+	}
+	want := map[string]string{
+		"127.217.125.151": "example.com",
+		"1.2.3.4":         "torproject.org",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("mapping mismatch: have %q, want %q", have, want)
+	}
+}
+
+// Tests that failed resolutions reported via ADDRMAP events are surfaced, and
+// that the temporary event subscription is dropped afterwards.
+func TestResolveFailure(t *testing.T) {
+	conn, done := replay(t, "resolve.txt")
+	defer done()
+
+	_, err := conn.Resolve(context.Background(), "127.0.0.1", true)
+	if rerr, ok := err.(*ResolveError); !ok || rerr.Address != "127.0.0.1" {
+		t.Errorf("resolve error mismatch: have %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+// Package control is a client for the tor control protocol.
+package control
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrClosed is returned for requests issued on a control connection that has
+// already been closed or torn down by tor.
+var ErrClosed = errors.New("control connection closed")
+
+// Conn is a connection to the control port of a tor instance. It is safe for
+// concurrent use, requests being pipelined to tor and replies matched up in
+// the order they arrive.
+type Conn struct {
+	conn   io.ReadWriteCloser
+	reader *textproto.Reader
+
+	pending []chan *Response // Requests waiting for their reply, in order
+	err     error            // Error that terminated the reader, if any
+	lock    sync.Mutex       // Lock protecting the writer and pending queue
+
+	subs      map[*Subscription]struct{} // Active event subscriptions
+	subLock   sync.RWMutex               // Lock protecting the subscriptions
+	eventLock sync.Mutex                 // Lock serializing event requests to tor
+
+	queue     []*Event      // Events waiting to be delivered to the subscribers
+	queueLock sync.Mutex    // Lock protecting the event queue
+	wake      chan struct{} // Notification channel for newly queued events
+	closed    chan struct{} // Channel closed when the connection is torn down
+}
+
+// NewConn wraps an established control connection (e.g. the one from tor's
+// embedded control socket) and starts processing the replies arriving on it.
+func NewConn(conn io.ReadWriteCloser) *Conn {
+	c := &Conn{
+		conn:   conn,
+		reader: textproto.NewReader(bufio.NewReader(conn)),
+		subs:   make(map[*Subscription]struct{}),
+		wake:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+	go c.loop()
+	go c.dispatch()
+	return c
+}
+
+// Close tears down the control connection, failing all pending requests.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Closed returns a channel that is closed when the control connection is torn
+// down, either locally or by tor terminating.
+func (c *Conn) Closed() <-chan struct{} {
+	return c.closed
+}
+
+// Request sends a raw command line to tor and waits for its reply. If the reply
+// is not successful, it is returned along with an *Error.
+//
+// If the context is cancelled before the reply arrives, the request is abandoned
+// and its reply discarded once it does arrive.
+func (c *Conn) Request(ctx context.Context, format string, args ...interface{}) (*Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	line := fmt.Sprintf(format, args...)
+	if strings.ContainsAny(line, "\r\n") {
+		return nil, errors.New("control command must be a single line")
+	}
+	// Send the request over and queue up the reply slot in the same order
+	reply := make(chan *Response, 1)
+
+	c.lock.Lock()
+	if c.err != nil {
+		c.lock.Unlock()
+		return nil, c.err
+	}
+	if _, err := io.WriteString(c.conn, line+"\r\n"); err != nil {
+		c.lock.Unlock()
+		return nil, err
+	}
+	c.pending = append(c.pending, reply)
+	c.lock.Unlock()
+
+	// Wait for the reply or the cancellation of the request
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case res, ok := <-reply:
+		if !ok {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			return nil, c.err
+		}
+		return res, res.Err()
+	}
+}
+
+// loop is the reader goroutine, parsing the replies arriving from tor and routing
+// them to the requests waiting for them, or to the event subscribers.
+func (c *Conn) loop() {
+	var err error
+	for {
+		var res *Response
+		if res, err = readResponse(c.reader); err != nil {
+			break
+		}
+		// Asynchronous event notifications are delivered out of band
+		if res.Code/100 == 6 {
+			c.enqueue(newEvent(res))
+			continue
+		}
+		c.lock.Lock()
+		if len(c.pending) == 0 {
+			c.lock.Unlock()
+			err = fmt.Errorf("unsolicited control reply: %d %s", res.Code, res.Text())
+			break
+		}
+		c.pending[0] <- res
+		c.pending = c.pending[1:]
+		c.lock.Unlock()
+	}
+	// Reading failed, fail all pending and future requests
+	if err == io.EOF {
+		err = ErrClosed
+	}
+	c.lock.Lock()
+	c.err = err
+	for _, reply := range c.pending {
+		close(reply)
+	}
+	c.pending = nil
+	c.lock.Unlock()
+
+	close(c.closed)
+	c.conn.Close()
+}
+
+// readResponse reads a full, possibly multi-line, reply from tor.
+func readResponse(r *textproto.Reader) (*Response, error) {
+	res := new(Response)
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) < 4 {
+			return nil, fmt.Errorf("malformed control reply line: %q", line)
+		}
+		code, err := strconv.Atoi(line[:3])
+		if err != nil || code < 100 {
+			return nil, fmt.Errorf("malformed control reply code: %q", line)
+		}
+		if len(res.Lines) > 0 && code != res.Code {
+			return nil, fmt.Errorf("control reply code mismatch: %d != %d", code, res.Code)
+		}
+		res.Code = code
+
+		entry := Line{Text: line[4:]}
+		switch line[3] {
+		case ' ':
+			res.Lines = append(res.Lines, entry)
+			return res, nil
+
+		case '-':
+			res.Lines = append(res.Lines, entry)
+
+		case '+':
+			if entry.Data, err = r.ReadDotLines(); err != nil {
+				return nil, err
+			}
+			res.Lines = append(res.Lines, entry)
+
+		default:
+			return nil, fmt.Errorf("malformed control reply separator: %q", line)
+		}
+	}
+}
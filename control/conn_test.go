@@ -0,0 +1,142 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exchange is a single request of a recorded control session, along with the
+// reply lines (and any events) tor sent after it.
+type exchange struct {
+	request string
+	replies []string
+}
+
+// loadTranscript parses a recorded control session from the testdata folder.
+// Lines starting with "> " are requests, "< " are replies and "#" comments.
+func loadTranscript(t *testing.T, name string) []exchange {
+	blob, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read transcript %s: %v", name, err)
+	}
+	var session []exchange
+	for i, line := range strings.Split(strings.TrimRight(string(blob), "\n"), "\n") {
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "> "):
+			session = append(session, exchange{request: line[2:]})
+		case strings.HasPrefix(line, "< ") && len(session) > 0:
+			session[len(session)-1].replies = append(session[len(session)-1].replies, line[2:])
+		default:
+			t.Fatalf("malformed transcript %s line %d: %q", name, i+1, line)
+		}
+	}
+	return session
+}
+
+// replay creates a control connection to a fake tor that replays a recorded
+// session, checking that the requests match the recorded ones exactly. The
+// returned function closes the connection and waits for the replay to finish.
+func replay(t *testing.T, name string) (*Conn, func()) {
+	session := loadTranscript(t, name)
+
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer server.Close()
+
+		reader := bufio.NewReader(server)
+		for _, step := range session {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Errorf("missing request %q: %v", step.request, err)
+				return
+			}
+			if line = strings.TrimSuffix(line, "\r\n"); line != step.request {
+				t.Errorf("request mismatch: have %q, want %q", line, step.request)
+				return
+			}
+			for _, reply := range step.replies {
+				if _, err := server.Write([]byte(reply + "\r\n")); err != nil {
+					t.Errorf("failed to send reply %q: %v", reply, err)
+					return
+				}
+			}
+		}
+		if line, err := reader.ReadString('\n'); err == nil {
+			t.Errorf("unexpected request: %q", strings.TrimSuffix(line, "\r\n"))
+		}
+	}()
+	conn := NewConn(client)
+	return conn, func() {
+		conn.Close()
+		<-done
+	}
+}
+
+// Tests that asynchronous events are delivered to the subscribers, multi-line
+// ones included, and that closing the last subscription unsubscribes from tor.
+func TestEvents(t *testing.T) {
+	conn, done := replay(t, "events.txt")
+	defer done()
+
+	ctx := context.Background()
+	events := make(chan *Event, 1)
+	sub, err := conn.Subscribe(ctx, events, "conf_changed")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if err := conn.SetConf(ctx, ConfEntry{Key: "MaxCircuitDirtiness", Value: "1234"}); err != nil {
+		t.Fatalf("failed to set conf: %v", err)
+	}
+	select {
+	case event := <-events:
+		want := &Event{
+			Type: "CONF_CHANGED",
+			Lines: []Line{
+				{Text: "CONF_CHANGED"},
+				{Text: "MaxCircuitDirtiness=1234"},
+				{Text: "OK"},
+			},
+		}
+		if !reflect.DeepEqual(event, want) {
+			t.Errorf("event mismatch: have %+v, want %+v", event, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("event not delivered")
+	}
+	if err := sub.Close(); err != nil {
+		t.Errorf("failed to unsubscribe: %v", err)
+	}
+}
+
+// Tests that requests fail with ErrClosed if tor closes the connection before
+// replying, and that later requests fail the same way.
+func TestClosedByTor(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		bufio.NewReader(server).ReadString('\n')
+		server.Close()
+	}()
+	conn := NewConn(client)
+	defer conn.Close()
+
+	if _, err := conn.Request(context.Background(), "GETINFO version"); err != ErrClosed {
+		t.Errorf("pending request error mismatch: have %v, want %v", err, ErrClosed)
+	}
+	<-conn.Closed()
+	if _, err := conn.Request(context.Background(), "GETINFO version"); err != ErrClosed {
+		t.Errorf("later request error mismatch: have %v, want %v", err, ErrClosed)
+	}
+}
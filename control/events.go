@@ -0,0 +1,176 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Event is an asynchronous event notification received from tor.
+type Event struct {
+	Type  string // Event type keyword (e.g. "CIRC" or "STATUS_CLIENT")
+	Text  string // Content of the first line, after the event type
+	Lines []Line // All the lines making up the event, including the first
+}
+
+// Arguments splits the first line of the event into its positional and keyword
+// arguments.
+func (e *Event) Arguments() ([]string, map[string]string) {
+	return ParseArguments(e.Text)
+}
+
+// newEvent converts an asynchronous reply into an event.
+func newEvent(res *Response) *Event {
+	event := &Event{Lines: res.Lines}
+	if len(res.Lines) > 0 {
+		event.Type, event.Text = res.Lines[0].Text, ""
+		if idx := strings.IndexByte(event.Type, ' '); idx >= 0 {
+			event.Type, event.Text = event.Type[:idx], event.Type[idx+1:]
+		}
+	}
+	return event
+}
+
+// Subscription is a registration for asynchronous events from tor, delivering
+// them on a channel.
+type Subscription struct {
+	conn   *Conn
+	events map[string]bool // Event types the subscription is interested in
+	sink   chan<- *Event   // Channel to deliver the events on
+	quit   chan struct{}   // Channel closed when the subscription is cancelled
+	once   sync.Once
+}
+
+// Subscribe requests tor to send the given types of asynchronous events, and
+// delivers them on the sink channel until the subscription is closed.
+//
+// Events are delivered in order. A slow subscriber holds up the delivery of the
+// events to other subscribers, but never the replies to requests.
+func (c *Conn) Subscribe(ctx context.Context, sink chan<- *Event, events ...string) (*Subscription, error) {
+	sub := &Subscription{
+		conn:   c,
+		events: make(map[string]bool),
+		sink:   sink,
+		quit:   make(chan struct{}),
+	}
+	for _, event := range events {
+		sub.events[strings.ToUpper(event)] = true
+	}
+	c.subLock.Lock()
+	c.subs[sub] = struct{}{}
+	c.subLock.Unlock()
+
+	if err := c.updateEvents(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Close cancels the subscription, stopping the delivery of events and requesting
+// tor to stop sending the ones nobody is interested in anymore.
+func (s *Subscription) Close() error {
+	var removed bool
+	s.once.Do(func() {
+		close(s.quit)
+
+		s.conn.subLock.Lock()
+		delete(s.conn.subs, s)
+		s.conn.subLock.Unlock()
+
+		removed = true
+	})
+	if !removed {
+		return nil
+	}
+	if err := s.conn.updateEvents(context.Background()); err != nil && err != ErrClosed {
+		return err
+	}
+	return nil
+}
+
+// updateEvents requests tor to send the union of the events the subscriptions
+// are interested in.
+func (c *Conn) updateEvents(ctx context.Context) error {
+	c.eventLock.Lock()
+	defer c.eventLock.Unlock()
+
+	c.subLock.RLock()
+	union := make(map[string]bool)
+	for sub := range c.subs {
+		for event := range sub.events {
+			union[event] = true
+		}
+	}
+	c.subLock.RUnlock()
+
+	events := make([]string, 0, len(union))
+	for event := range union {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	if len(events) == 0 {
+		_, err := c.Request(ctx, "SETEVENTS")
+		return err
+	}
+	_, err := c.Request(ctx, "SETEVENTS %s", strings.Join(events, " "))
+	return err
+}
+
+// enqueue schedules an asynchronous event for delivery to the subscribers.
+func (c *Conn) enqueue(event *Event) {
+	c.queueLock.Lock()
+	c.queue = append(c.queue, event)
+	c.queueLock.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch is the event delivery goroutine, forwarding the queued events to the
+// interested subscribers. It terminates when the connection is torn down.
+func (c *Conn) dispatch() {
+	for {
+		select {
+		case <-c.wake:
+		case <-c.closed:
+			return
+		}
+		for {
+			c.queueLock.Lock()
+			if len(c.queue) == 0 {
+				c.queueLock.Unlock()
+				break
+			}
+			event := c.queue[0]
+			c.queue[0] = nil
+			c.queue = c.queue[1:]
+			c.queueLock.Unlock()
+
+			c.subLock.RLock()
+			var subs []*Subscription
+			for sub := range c.subs {
+				if sub.events[event.Type] {
+					subs = append(subs, sub)
+				}
+			}
+			c.subLock.RUnlock()
+
+			for _, sub := range subs {
+				select {
+				case sub.sink <- event:
+				case <-sub.quit:
+				case <-c.closed:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OnionPort maps a virtual port of an onion service to a local target.
+type OnionPort struct {
+	Virtual int    // Port the service is reachable at within the Tor network
+	Target  string // Local address:port, port, or unix:path (see UnixTarget) to forward to (default same port on localhost)
+}
+
+// UnixTarget returns the onion port target forwarding to a unix socket, quoted
+// in tor's syntax so the path may contain spaces, quotes or any other byte but
+// NUL. Tor splits the ADD_ONION arguments on spaces regardless of quoting, so
+// all the bytes that aren't printable ASCII are hex escaped.
+func UnixTarget(path string) string {
+	target := []byte(`unix:"`)
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c <= ' ' || c >= 0x7f || c == '"' || c == '\\':
+			target = append(target, fmt.Sprintf(`\x%02x`, c)...)
+		default:
+			target = append(target, c)
+		}
+	}
+	return string(append(target, '"'))
+}
+
+// validTarget returns whether an onion port target can be passed to tor as a
+// single argument: without whitespace, and with quotes only around the path of
+// a unix socket target.
+func validTarget(target string) bool {
+	if strings.ContainsAny(target, " \t\r\n") {
+		return false
+	}
+	if strings.HasPrefix(target, `unix:"`) && strings.HasSuffix(target, `"`) && len(target) > len(`unix:""`) {
+		target = target[len(`unix:"`) : len(target)-1]
+	}
+	return !strings.Contains(target, `"`)
+}
+
+// OnionClient is a client authorized to access a v2 onion service with basic
+// authorization.
+type OnionClient struct {
+	Name   string // Name of the client
+	Cookie string // Authorization cookie of the client, empty to generate one
+}
+
+// AddOnionRequest describes an onion service to create.
+type AddOnionRequest struct {
+	// KeyType is the type of the service's private key: "ED25519-V3" for v3 or
+	// "RSA1024" for v2 services. If Key is empty, a new key of the type (or the
+	// best type if empty too) is generated.
+	KeyType string
+	Key     string // Base64 encoded private key, empty to generate a new one
+
+	Ports      []OnionPort // Virtual ports of the service, at least one required
+	MaxStreams int         // Maximum number of concurrent streams per rendezvous circuit, 0 for unlimited
+
+	DiscardKey             bool // Do not return the generated private key
+	Detach                 bool // Keep the service running after the control connection closes
+	NonAnonymous           bool // Create a single onion service (requires a non-anonymous tor)
+	MaxStreamsCloseCircuit bool // Close the circuit instead of rejecting streams over MaxStreams
+
+	Clients []OnionClient // Clients for basic authorization (v2 services only)
+}
+
+// AddOnionResponse is the outcome of creating an onion service.
+type AddOnionResponse struct {
+	ServiceID string            // Onion address of the service, without the .onion suffix
+	KeyType   string            // Type of the generated private key, if any
+	Key       string            // Base64 encoded generated private key, if any
+	Clients   map[string]string // Authorization cookies of the clients, by name
+}
+
+// AddOnion creates an onion service. Unless detached, the service is tied to the
+// control connection and is removed when it closes.
+func (c *Conn) AddOnion(ctx context.Context, req *AddOnionRequest) (*AddOnionResponse, error) {
+	if len(req.Ports) == 0 {
+		return nil, errors.New("onion service without ports")
+	}
+	// Assemble the key and the flags of the service
+	var args []string
+	switch {
+	case req.Key != "":
+		if req.KeyType == "" {
+			return nil, errors.New("onion service key without type")
+		}
+		args = append(args, req.KeyType+":"+req.Key)
+	case req.KeyType != "":
+		args = append(args, "NEW:"+req.KeyType)
+	default:
+		args = append(args, "NEW:BEST")
+	}
+	var flags []string
+	if req.DiscardKey {
+		flags = append(flags, "DiscardPK")
+	}
+	if req.Detach {
+		flags = append(flags, "Detach")
+	}
+	if len(req.Clients) > 0 {
+		flags = append(flags, "BasicAuth")
+	}
+	if req.NonAnonymous {
+		flags = append(flags, "NonAnonymous")
+	}
+	if req.MaxStreamsCloseCircuit {
+		flags = append(flags, "MaxStreamsCloseCircuit")
+	}
+	if len(flags) > 0 {
+		args = append(args, "Flags="+strings.Join(flags, ","))
+	}
+	if req.MaxStreams > 0 {
+		args = append(args, "MaxStreams="+strconv.Itoa(req.MaxStreams))
+	}
+	// Assemble the ports and the authorized clients
+	for _, port := range req.Ports {
+		if !validTarget(port.Target) {
+			return nil, fmt.Errorf("invalid onion port target: %q", port.Target)
+		}
+		arg := "Port=" + strconv.Itoa(port.Virtual)
+		if port.Target != "" {
+			arg += "," + port.Target
+		}
+		args = append(args, arg)
+	}
+	for _, client := range req.Clients {
+		if !isKeyword(client.Name) || strings.ContainsAny(client.Cookie, " \"") {
+			return nil, fmt.Errorf("invalid onion client: %q", client.Name)
+		}
+		arg := "ClientAuth=" + client.Name
+		if client.Cookie != "" {
+			arg += ":" + client.Cookie
+		}
+		args = append(args, arg)
+	}
+	res, err := c.Request(ctx, "ADD_ONION %s", strings.Join(args, " "))
+	if err != nil {
+		return nil, err
+	}
+	// Collect the details of the created service
+	onion := &AddOnionResponse{Clients: make(map[string]string)}
+	for _, pair := range res.keyValues() {
+		switch pair[0] {
+		case "ServiceID":
+			onion.ServiceID = pair[1]
+		case "PrivateKey":
+			if idx := strings.IndexByte(pair[1], ':'); idx >= 0 {
+				onion.KeyType, onion.Key = pair[1][:idx], pair[1][idx+1:]
+			}
+		case "ClientAuth":
+			if idx := strings.IndexByte(pair[1], ':'); idx >= 0 {
+				onion.Clients[pair[1][:idx]] = pair[1][idx+1:]
+			}
+		}
+	}
+	if onion.ServiceID == "" {
+		return nil, errors.New("onion service created without service id")
+	}
+	return onion, nil
+}
+
+// DelOnion removes an onion service created by this connection, or a detached
+// one, by its onion address (without the .onion suffix).
+func (c *Conn) DelOnion(ctx context.Context, serviceID string) error {
+	_, err := c.Request(ctx, "DEL_ONION %s", strings.TrimSuffix(serviceID, ".onion"))
+	return err
+}
+
+// HSFetch requests tor to fetch the descriptor of a v2 onion service, identified
+// by its onion address (without the .onion suffix) or by "v2-" prefixed
+// descriptor ID, optionally from specific directory servers. The outcome is
+// reported via HS_DESC and HS_DESC_CONTENT events.
+//
+// Tor 0.3.5 does not support fetching v3 descriptors on request.
+func (c *Conn) HSFetch(ctx context.Context, address string, servers ...string) error {
+	args := []string{strings.TrimSuffix(address, ".onion")}
+	for _, server := range servers {
+		args = append(args, "SERVER="+server)
+	}
+	_, err := c.Request(ctx, "HSFETCH %s", strings.Join(args, " "))
+	return err
+}
@@ -0,0 +1,92 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// Tests that onion services are requested with the correct arguments, that the
+// generated keys are parsed out of the replies and that services can be removed.
+func TestAddDelOnion(t *testing.T) {
+	conn, done := replay(t, "onion.txt")
+	defer done()
+
+	ctx := context.Background()
+	have, err := conn.AddOnion(ctx, &AddOnionRequest{
+		KeyType: "ED25519-V3",
+		Ports:   []OnionPort{{Virtual: 80, Target: "unix:/tmp/x.sock"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to add onion: %v", err)
+	}
+	want := &AddOnionResponse{
+		ServiceID: "52u7dlg2feo7g32dkrj6ia7ip3tr7wnehsdh27o26ndhj36iuxymewqd",
+		KeyType:   "ED25519-V3",
+		Key:       "iBhFOmXxeEayP96lYBA0cTDAHd0M6asI5oh4S+GEeXELmeHpiHxa1Kq4J8+zAcTeEICCOB7+5XPbxed6FH8Nog==",
+		Clients:   map[string]string{},
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("onion mismatch: have %+v, want %+v", have, want)
+	}
+	discarded, err := conn.AddOnion(ctx, &AddOnionRequest{
+		KeyType:    "ED25519-V3",
+		Ports:      []OnionPort{{Virtual: 80}, {Virtual: 443, Target: "127.0.0.1:8443"}},
+		MaxStreams: 5,
+		DiscardKey: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to add keyless onion: %v", err)
+	}
+	want = &AddOnionResponse{
+		ServiceID: "bbeo7hnzfyht3vmmp6erlqc22ps53db2j2ubflsad3wrvbhwdhtp23id",
+		Clients:   map[string]string{},
+	}
+	if !reflect.DeepEqual(discarded, want) {
+		t.Errorf("keyless onion mismatch: have %+v, want %+v", discarded, want)
+	}
+	if err := conn.DelOnion(ctx, have.ServiceID+".onion"); err != nil {
+		t.Errorf("failed to delete onion: %v", err)
+	}
+	err = conn.DelOnion(ctx, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaad")
+	if cerr, ok := err.(*Error); !ok || cerr.Code != 512 {
+		t.Errorf("malformed onion error mismatch: have %v", err)
+	}
+}
+
+// Tests that unix socket targets are quoted and escaped into a single argument
+// tor unescapes back into the path, and that other targets are validated.
+func TestUnixTarget(t *testing.T) {
+	tests := []struct {
+		path   string
+		target string
+	}{
+		{"/tmp/x.sock", `unix:"/tmp/x.sock"`},
+		{"/tmp/with space/80.sock", `unix:"/tmp/with\x20space/80.sock"`},
+		{`/tmp/"quoted"\dir/80.sock`, `unix:"/tmp/\x22quoted\x22\x5cdir/80.sock"`},
+		{"/tmp/a,b\tc\nd/80.sock", `unix:"/tmp/a,b\x09c\x0ad/80.sock"`},
+		{"/tmp/ünicode", `unix:"/tmp/\xc3\xbcnicode"`},
+	}
+	for _, tt := range tests {
+		target := UnixTarget(tt.path)
+		if target != tt.target {
+			t.Errorf("%q: target mismatch: have %s, want %s", tt.path, target, tt.target)
+		}
+		if !validTarget(target) {
+			t.Errorf("%q: quoted target rejected: %s", tt.path, target)
+		}
+	}
+	for _, target := range []string{"", "8080", "127.0.0.1:8080", "unix:/tmp/x.sock"} {
+		if !validTarget(target) {
+			t.Errorf("valid target rejected: %q", target)
+		}
+	}
+	for _, target := range []string{"unix:/tmp/with space", `unix:"/tmp/with space"`, `unix:"/tmp/"x"`, `unix:""`, `unix:"`, `"127.0.0.1:80"`, "127.0.0.1:80\r\n"} {
+		if validTarget(target) {
+			t.Errorf("invalid target accepted: %q", target)
+		}
+	}
+}
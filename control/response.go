@@ -0,0 +1,74 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package control
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Line is a single line of a control reply.
+type Line struct {
+	Text string   // Content of the line after the status code and separator
+	Data []string // Dot-decoded data block following the line, if any
+}
+
+// Response is a complete, possibly multi-line, reply from the control port.
+type Response struct {
+	Code  int    // Three digit status code of the reply
+	Lines []Line // Individual lines making up the reply
+}
+
+// OK returns whether the reply signals a successfully executed command.
+func (r *Response) OK() bool {
+	return r.Code >= 200 && r.Code < 300
+}
+
+// Text returns the content of the last reply line, which for single line replies
+// and failures contains the status message.
+func (r *Response) Text() string {
+	if len(r.Lines) == 0 {
+		return ""
+	}
+	return r.Lines[len(r.Lines)-1].Text
+}
+
+// Err returns an *Error if the reply signals a failure, or nil otherwise.
+func (r *Response) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return &Error{Code: r.Code, Message: r.Text()}
+}
+
+// Error is a failure reply received from the control port.
+type Error struct {
+	Code    int    // Three digit status code of the reply
+	Message string // Human readable error message from tor
+}
+
+// Error implements error, formatting the control failure.
+func (e *Error) Error() string {
+	return fmt.Sprintf("tor control error %d: %s", e.Code, e.Message)
+}
+
+// keyValues splits the lines of a reply into key-value pairs, as returned by the
+// GETINFO and GETCONF commands. The trailing "OK" line is skipped.
+func (r *Response) keyValues() [][2]string {
+	var pairs [][2]string
+	for i, line := range r.Lines {
+		if i == len(r.Lines)-1 && line.Text == "OK" && line.Data == nil {
+			break
+		}
+		key, value := line.Text, ""
+		if idx := strings.IndexByte(line.Text, '='); idx >= 0 {
+			key, value = line.Text[:idx], line.Text[idx+1:]
+		}
+		if line.Data != nil {
+			value = strings.Join(line.Data, "\n")
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs
+}
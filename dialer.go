@@ -0,0 +1,166 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// isolationKey is the context key of the per-call isolation keys.
+type isolationKey struct{}
+
+// WithIsolation returns a context that makes the connections dialed with it use
+// circuits separate from the ones dialed with other isolation keys (or none).
+//
+// The key is sent to tor as the SOCKS username and password, so it relies on the
+// SocksPort isolating by SOCKS authentication, which tor does by default.
+func WithIsolation(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, isolationKey{}, key)
+}
+
+// isolation returns the isolation key of a context, empty if none is set.
+func isolation(ctx context.Context) string {
+	key, _ := ctx.Value(isolationKey{}).(string)
+	return key
+}
+
+// torAddr is a network address reached through tor, kept unresolved.
+type torAddr string
+
+// Network implements net.Addr.
+func (a torAddr) Network() string { return "tcp" }
+
+// String implements net.Addr.
+func (a torAddr) String() string { return string(a) }
+
+// Dialer dials connections through the embedded tor. Hostnames (including .onion
+// addresses) are resolved by tor within the Tor network, never locally.
+//
+// Connections are handed to tor directly over socketpairs instead of through its
+// SocksPort, so no listener needs to be opened for them. Tor is started with its
+// SocksPort disabled unless one is set in Config.Options, Config.Args or the
+// in-memory torrc (overriding the on-disk torrc), keeping other local processes
+// from using it.
+type Dialer struct {
+	tor *Tor
+
+	// Isolation is the isolation key of the connections that don't have one set
+	// in their dial context via WithIsolation. Empty means no isolation.
+	Isolation string
+
+	// Exits restricts the exit relays of the connections that don't have exit
+	// constraints set in their dial context via WithExitConstraints. Nil means
+	// tor picks the exits on its own.
+	Exits *ExitConstraints
+}
+
+// Dialer returns a dialer connecting through the embedded tor.
+func (t *Tor) Dialer() *Dialer {
+	return &Dialer{tor: t}
+}
+
+// Dial connects to the address on the named network through tor. Only "tcp" is
+// supported, see DialContext.
+func (d *Dialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext connects to the address on the named network through tor, using
+// the context for the connection establishment and the isolation key within it,
+// if any.
+//
+// Only the "tcp" network is supported. The address family of the connection is
+// up to the exit relay, so "tcp4" and "tcp6" are rejected instead of silently
+// ignoring the family they ask for.
+//
+// The returned connection is a *Conn, reporting the circuit it went through. With
+// exit constraints, dialing fails with ErrNoExit if no relay satisfies them, or
+// with a *CircuitError if their dedicated circuit fails to build.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if network != "tcp" {
+		return nil, &net.OpError{Op: "dial", Net: network, Addr: torAddr(addr), Err: net.UnknownNetworkError(network)}
+	}
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Addr: torAddr(addr), Err: err}
+	}
+	key := isolation(ctx)
+	if key == "" {
+		key = d.Isolation
+	}
+	exits := exitConstraints(ctx)
+	if exits == nil {
+		exits = d.Exits
+	}
+	// Pinned connections need tor to leave their streams to the tracker, exit
+	// constrained ones a dedicated circuit to be pinned to
+	circuit := pinnedCircuit(ctx)
+	constrained := circuit == 0 && exits != nil && onionAddress(host) == ""
+	if circuit != 0 || constrained {
+		if err := d.tor.conns.pin(); err != nil {
+			return nil, &net.OpError{Op: "dial", Net: network, Addr: torAddr(addr), Err: err}
+		}
+	}
+	if constrained {
+		if circuit, err = d.tor.conns.exitCircuit(ctx, exits, port, key); err != nil {
+			d.tor.conns.unpin()
+			return nil, &net.OpError{Op: "dial", Net: network, Addr: torAddr(addr), Err: err}
+		}
+	}
+	conn, id, err := d.tor.proc.SocksConn()
+	if err != nil {
+		if circuit != 0 {
+			d.tor.conns.unpin()
+		}
+		return nil, &net.OpError{Op: "dial", Net: network, Addr: torAddr(addr), Err: err}
+	}
+	record := d.tor.conns.watch(id, circuit)
+	if _, err := handshake(ctx, conn, socksCmdConnect, host, port, key); err != nil {
+		conn.Close()
+		stream := d.tor.conns.forget(record)
+		if reply, ok := err.(SocksError); ok {
+			err = &StreamError{Reply: reply, Reason: stream.reason, Remote: stream.remote, Onion: stream.onion}
+		}
+		return nil, &net.OpError{Op: "dial", Net: network, Addr: torAddr(addr), Err: err}
+	}
+	return &Conn{Conn: conn, tracker: d.tor.conns, record: record}, nil
+}
+
+// handshake runs a SOCKS request over a connection to tor, aborting it if the
+// context is cancelled or its deadline expires.
+func handshake(ctx context.Context, conn net.Conn, command byte, host string, port int, isolation string) (string, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Interrupt the blocking handshake when the context is cancelled
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+	bound, err := socksRequest(conn, command, host, port, isolation)
+
+	close(done)
+	<-stopped
+	conn.SetDeadline(time.Time{})
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		// The connection deadline may fire a tad before the context's
+		if err, ok := err.(net.Error); ok && err.Timeout() {
+			if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+				return "", context.DeadlineExceeded
+			}
+		}
+	}
+	return bound, err
+}
@@ -0,0 +1,30 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"net"
+	"testing"
+)
+
+// Tests that networks asking for an address family (or not TCP at all) are
+// rejected, as the family is up to the exit relay.
+func TestDialerNetworks(t *testing.T) {
+	for _, network := range []string{"tcp4", "tcp6", "udp", "unix"} {
+		conn, err := new(Dialer).Dial(network, "example.com:80")
+		if err == nil {
+			conn.Close()
+			t.Errorf("%s: dial succeeded", network)
+			continue
+		}
+		operr, ok := err.(*net.OpError)
+		if !ok {
+			t.Errorf("%s: error type mismatch: have %T (%v), want *net.OpError", network, err, err)
+			continue
+		}
+		if _, ok := operr.Err.(net.UnknownNetworkError); !ok {
+			t.Errorf("%s: error mismatch: have %v, want unknown network", network, operr.Err)
+		}
+	}
+}
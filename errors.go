@@ -0,0 +1,240 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SocksError is a failure reply code tor answered a SOCKS request with. Tor
+// derives these from the END reasons of the streams it failed to open, so they
+// are coarser than the reasons themselves.
+type SocksError byte
+
+// Failure replies of SOCKS requests (RFC 1928).
+const (
+	SocksGeneralFailure      SocksError = 0x01
+	SocksNotAllowed          SocksError = 0x02
+	SocksNetUnreachable      SocksError = 0x03
+	SocksHostUnreachable     SocksError = 0x04
+	SocksConnRefused         SocksError = 0x05
+	SocksTTLExpired          SocksError = 0x06
+	SocksCommandNotSupported SocksError = 0x07
+	SocksAddrNotSupported    SocksError = 0x08
+)
+
+// socksErrorTexts are the descriptions of the SOCKS failure replies.
+var socksErrorTexts = map[SocksError]string{
+	SocksGeneralFailure:      "general SOCKS server failure",
+	SocksNotAllowed:          "connection not allowed by ruleset",
+	SocksNetUnreachable:      "network unreachable",
+	SocksHostUnreachable:     "host unreachable",
+	SocksConnRefused:         "connection refused",
+	SocksTTLExpired:          "TTL expired",
+	SocksCommandNotSupported: "command not supported",
+	SocksAddrNotSupported:    "address type not supported",
+}
+
+// Error implements error.
+func (e SocksError) Error() string {
+	if text, ok := socksErrorTexts[e]; ok {
+		return text
+	}
+	return fmt.Sprintf("SOCKS reply code %d", byte(e))
+}
+
+// EndReason is the reason tor ended a stream with, named as in the control
+// protocol. Besides the reasons of the RELAY_END cells (tor-spec.txt 6.3), tor
+// has a few local ones for streams it never sent out.
+type EndReason string
+
+// Reasons of tor for ending streams (core/or/reasons.c).
+const (
+	EndMisc           EndReason = "MISC"
+	EndResolveFailed  EndReason = "RESOLVEFAILED"
+	EndConnRefused    EndReason = "CONNECTREFUSED"
+	EndExitPolicy     EndReason = "EXITPOLICY"
+	EndDestroy        EndReason = "DESTROY"
+	EndDone           EndReason = "DONE"
+	EndTimeout        EndReason = "TIMEOUT"
+	EndNoRoute        EndReason = "NOROUTE"
+	EndHibernating    EndReason = "HIBERNATING"
+	EndInternal       EndReason = "INTERNAL"
+	EndResourceLimit  EndReason = "RESOURCELIMIT"
+	EndConnReset      EndReason = "CONNRESET"
+	EndTorProtocol    EndReason = "TORPROTOCOL"
+	EndNotDirectory   EndReason = "NOTDIRECTORY"
+	EndCantAttach     EndReason = "CANT_ATTACH"
+	EndNetUnreachable EndReason = "NET_UNREACHABLE"
+	EndSocksProtocol  EndReason = "SOCKS_PROTOCOL"
+	EndPrivateAddr    EndReason = "PRIVATE_ADDR"
+)
+
+// endReasonTexts are the descriptions of the END reasons.
+var endReasonTexts = map[EndReason]string{
+	EndMisc:           "misc error",
+	EndResolveFailed:  "resolve failed",
+	EndConnRefused:    "connection refused",
+	EndExitPolicy:     "exit policy failed",
+	EndDestroy:        "destroyed",
+	EndDone:           "closed normally",
+	EndTimeout:        "gave up (timeout)",
+	EndNoRoute:        "no route to host",
+	EndHibernating:    "server is hibernating",
+	EndInternal:       "internal error at server",
+	EndResourceLimit:  "server out of resources",
+	EndConnReset:      "connection reset",
+	EndTorProtocol:    "Tor protocol error",
+	EndNotDirectory:   "not a directory",
+	EndCantAttach:     "no circuit to attach to",
+	EndNetUnreachable: "network unreachable",
+	EndSocksProtocol:  "SOCKS protocol error",
+	EndPrivateAddr:    "private address",
+}
+
+// Error implements error.
+func (r EndReason) Error() string {
+	if text, ok := endReasonTexts[r]; ok {
+		return text
+	}
+	return string(r)
+}
+
+// endReasonCodes are the RELAY_END cell codes of the reasons that tor may send.
+var endReasonCodes = map[EndReason]int{
+	EndMisc:          1,
+	EndResolveFailed: 2,
+	EndConnRefused:   3,
+	EndExitPolicy:    4,
+	EndDestroy:       5,
+	EndDone:          6,
+	EndTimeout:       7,
+	EndNoRoute:       8,
+	EndHibernating:   9,
+	EndInternal:      10,
+	EndResourceLimit: 11,
+	EndConnReset:     12,
+	EndTorProtocol:   13,
+	EndNotDirectory:  14,
+}
+
+// code returns the RELAY_END cell code of the reason, falling back to the one of
+// EndMisc for tor's local reasons.
+func (r EndReason) code() int {
+	if code, ok := endReasonCodes[r]; ok {
+		return code
+	}
+	return endReasonCodes[EndMisc]
+}
+
+// OnionError is the reason tor failed to fetch the descriptor of an onion
+// service, as reported by the HS_DESC events.
+type OnionError string
+
+// Reasons of tor for failing to fetch onion service descriptors.
+const (
+	OnionNotFound      OnionError = "NOT_FOUND"      // No directory had the descriptor
+	OnionBadDesc       OnionError = "BAD_DESC"       // The descriptor could not be parsed or decrypted
+	OnionQueryRejected OnionError = "QUERY_REJECTED" // The directory rejected the query
+	OnionNoHSDir       OnionError = "QUERY_NO_HSDIR" // No directory was available to query
+	OnionUnexpected    OnionError = "UNEXPECTED"     // The directory answered unexpectedly
+)
+
+// onionErrorTexts are the descriptions of the descriptor fetch failures.
+var onionErrorTexts = map[OnionError]string{
+	OnionNotFound:      "onion service descriptor not found",
+	OnionBadDesc:       "bad onion service descriptor",
+	OnionQueryRejected: "onion service descriptor query rejected",
+	OnionNoHSDir:       "no onion service directory to query",
+	OnionUnexpected:    "unexpected onion service directory response",
+}
+
+// Error implements error.
+func (e OnionError) Error() string {
+	if text, ok := onionErrorTexts[e]; ok {
+		return text
+	}
+	return "onion service descriptor fetch failed: " + string(e)
+}
+
+// StreamError is returned when tor fails to open a stream, carrying both the
+// SOCKS reply and, if tor reported them, the END reason of the stream and the
+// descriptor fetch failure of the onion service it targeted.
+//
+// errors.Is matches a StreamError against any of its SocksError, EndReason or
+// OnionError values.
+type StreamError struct {
+	Reply  SocksError // SOCKS reply tor failed the request with
+	Reason EndReason  // END reason of the stream, empty if unknown
+	Remote bool       // Whether the END reason was sent by the exit or service
+	Onion  OnionError // Descriptor fetch failure of the onion service, if any
+}
+
+// Error implements error.
+func (e *StreamError) Error() string {
+	msg := "SOCKS request failed: " + e.Reply.Error()
+	if e.Reason != "" {
+		msg += ", stream ended: " + e.Reason.Error()
+		if e.Remote {
+			msg += " (remote)"
+		}
+	}
+	if e.Onion != "" {
+		msg += ", " + e.Onion.Error()
+	}
+	return msg
+}
+
+// Is reports whether the target is the END reason or onion service failure of
+// the stream. The SOCKS reply is matched via Unwrap.
+func (e *StreamError) Is(target error) bool {
+	switch target := target.(type) {
+	case EndReason:
+		return e.Reason != "" && e.Reason == target
+	case OnionError:
+		return e.Onion != "" && e.Onion == target
+	}
+	return false
+}
+
+// Unwrap returns the SOCKS reply of the failure.
+func (e *StreamError) Unwrap() error {
+	return e.Reply
+}
+
+// temporary reports whether the failure is specific to the circuit the stream
+// was attempted on, so retrying on a fresh one may succeed.
+func (e *StreamError) temporary() bool {
+	switch e.Reason {
+	case EndExitPolicy, EndTimeout, EndDestroy, EndResourceLimit, EndHibernating, EndMisc:
+		return true
+	case "":
+		// Tor did not report the reason, guess it from the reply
+		switch e.Reply {
+		case SocksGeneralFailure, SocksNotAllowed, SocksTTLExpired:
+			return true
+		}
+	}
+	return false
+}
+
+// CircuitError is returned when tor fails to build a circuit a connection was to
+// be sent over, carrying the reason tor reported for it.
+type CircuitError struct {
+	Reason string // Reason tor failed the circuit with (e.g. TIMEOUT or NOPATH)
+	Remote bool   // Whether the reason was sent by a relay of the circuit
+}
+
+// Error implements error.
+func (e *CircuitError) Error() string {
+	msg := "circuit failed"
+	if e.Reason != "" {
+		msg += ": " + strings.ToLower(e.Reason)
+		if e.Remote {
+			msg += " (remote)"
+		}
+	}
+	return msg
+}
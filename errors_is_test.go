@@ -0,0 +1,67 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+//go:build go1.13
+// +build go1.13
+
+package libtor
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// Tests that stream failures match the SOCKS reply, END reason and onion service
+// failure they carry with errors.Is, even when wrapped, but none other.
+func TestStreamErrorIs(t *testing.T) {
+	replies := []SocksError{
+		SocksGeneralFailure, SocksNotAllowed, SocksNetUnreachable, SocksHostUnreachable,
+		SocksConnRefused, SocksTTLExpired, SocksCommandNotSupported, SocksAddrNotSupported,
+	}
+	reasons := []EndReason{
+		EndMisc, EndResolveFailed, EndConnRefused, EndExitPolicy, EndDestroy, EndDone,
+		EndTimeout, EndNoRoute, EndHibernating, EndInternal, EndResourceLimit, EndConnReset,
+		EndTorProtocol, EndNotDirectory, EndCantAttach, EndNetUnreachable, EndSocksProtocol,
+		EndPrivateAddr,
+	}
+	onions := []OnionError{
+		OnionNotFound, OnionBadDesc, OnionQueryRejected, OnionNoHSDir, OnionUnexpected,
+	}
+	check := func(err error, target error, want bool) {
+		t.Helper()
+		if have := errors.Is(err, target); have != want {
+			t.Errorf("%v: match of %#v mismatch: have %v, want %v", err, target, have, want)
+		}
+		wrapped := &net.OpError{Op: "dial", Net: "tcp", Err: err}
+		if have := errors.Is(wrapped, target); have != want {
+			t.Errorf("%v: wrapped match of %#v mismatch: have %v, want %v", err, target, have, want)
+		}
+	}
+	for _, reply := range replies {
+		err := &StreamError{Reply: reply}
+		for _, target := range replies {
+			check(err, target, target == reply)
+		}
+		for _, target := range reasons {
+			check(err, target, false)
+		}
+		for _, target := range onions {
+			check(err, target, false)
+		}
+	}
+	for _, reason := range reasons {
+		err := &StreamError{Reply: SocksGeneralFailure, Reason: reason}
+		for _, target := range reasons {
+			check(err, target, target == reason)
+		}
+	}
+	for _, onion := range onions {
+		err := &StreamError{Reply: SocksHostUnreachable, Reason: EndResolveFailed, Onion: onion}
+		for _, target := range onions {
+			check(err, target, target == onion)
+		}
+		check(err, SocksHostUnreachable, true)
+		check(err, EndResolveFailed, true)
+	}
+}
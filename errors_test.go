@@ -0,0 +1,86 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import "testing"
+
+// Tests that only the stream failures specific to the circuit they were tried on
+// are deemed temporary, guessing from the SOCKS reply if tor gave no reason.
+func TestStreamErrorTemporary(t *testing.T) {
+	tests := []struct {
+		reply  SocksError
+		reason EndReason
+		want   bool
+	}{
+		// Reasons reported by tor, regardless of the reply they map to
+		{SocksGeneralFailure, EndMisc, true},
+		{SocksNotAllowed, EndExitPolicy, true},
+		{SocksGeneralFailure, EndDestroy, true},
+		{SocksTTLExpired, EndTimeout, true},
+		{SocksGeneralFailure, EndHibernating, true},
+		{SocksGeneralFailure, EndResourceLimit, true},
+		{SocksHostUnreachable, EndResolveFailed, false},
+		{SocksConnRefused, EndConnRefused, false},
+		{SocksGeneralFailure, EndDone, false},
+		{SocksHostUnreachable, EndNoRoute, false},
+		{SocksGeneralFailure, EndInternal, false},
+		{SocksConnRefused, EndConnReset, false},
+		{SocksGeneralFailure, EndTorProtocol, false},
+		{SocksGeneralFailure, EndNotDirectory, false},
+		{SocksGeneralFailure, EndCantAttach, false},
+		{SocksNetUnreachable, EndNetUnreachable, false},
+		{SocksGeneralFailure, EndSocksProtocol, false},
+		{SocksNotAllowed, EndPrivateAddr, false},
+
+		// No reason reported, guessed from the reply
+		{SocksGeneralFailure, "", true},
+		{SocksNotAllowed, "", true},
+		{SocksTTLExpired, "", true},
+		{SocksNetUnreachable, "", false},
+		{SocksHostUnreachable, "", false},
+		{SocksConnRefused, "", false},
+		{SocksCommandNotSupported, "", false},
+		{SocksAddrNotSupported, "", false},
+	}
+	for _, tt := range tests {
+		err := &StreamError{Reply: tt.reply, Reason: tt.reason}
+		if have := err.temporary(); have != tt.want {
+			t.Errorf("%v: temporary mismatch: have %v, want %v", err, have, tt.want)
+		}
+	}
+}
+
+// Tests that END reasons map to their RELAY_END cell codes, tor's local reasons
+// falling back to the miscellaneous one.
+func TestEndReasonCode(t *testing.T) {
+	tests := []struct {
+		reason EndReason
+		code   int
+	}{
+		{EndMisc, 1},
+		{EndResolveFailed, 2},
+		{EndConnRefused, 3},
+		{EndExitPolicy, 4},
+		{EndDestroy, 5},
+		{EndDone, 6},
+		{EndTimeout, 7},
+		{EndNoRoute, 8},
+		{EndHibernating, 9},
+		{EndInternal, 10},
+		{EndResourceLimit, 11},
+		{EndConnReset, 12},
+		{EndTorProtocol, 13},
+		{EndNotDirectory, 14},
+		{EndCantAttach, 1},
+		{EndNetUnreachable, 1},
+		{EndSocksProtocol, 1},
+		{EndPrivateAddr, 1},
+		{"BOGUS", 1},
+	}
+	for _, tt := range tests {
+		if have := tt.reason.code(); have != tt.code {
+			t.Errorf("%s: code mismatch: have %d, want %d", tt.reason, have, tt.code)
+		}
+	}
+}
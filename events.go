@@ -0,0 +1,408 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipsn/go-libtor/libtor"
+)
+
+// EventType is the type of an event delivered in-process by the embedded tor.
+type EventType int
+
+// Types of the events the embedded tor delivers in-process, each corresponding
+// to the control protocol event of the same name.
+const (
+	EventCirc            EventType = libtor.EventCirc         // CIRC events, delivered as *CircEvent
+	EventStream          EventType = libtor.EventStream       // STREAM events, delivered as *StreamEvent
+	EventORConn          EventType = libtor.EventORConn       // ORCONN events, delivered as *ORConnEvent
+	EventBandwidth       EventType = libtor.EventBW           // BW events, delivered as *BandwidthEvent
+	EventStreamBandwidth EventType = libtor.EventStreamBW     // STREAM_BW events, delivered as *StreamBandwidthEvent
+	EventHSDesc          EventType = libtor.EventHSDesc       // HS_DESC events, delivered as *HSDescEvent
+	EventGuard           EventType = libtor.EventGuard        // GUARD events, delivered as *GuardEvent
+	EventNewConsensus    EventType = libtor.EventNewConsensus // NEWCONSENSUS events, delivered as *ConsensusEvent
+)
+
+// eventTypeNames are the control protocol names of the event types.
+var eventTypeNames = map[EventType]string{
+	EventCirc:            "CIRC",
+	EventStream:          "STREAM",
+	EventORConn:          "ORCONN",
+	EventBandwidth:       "BW",
+	EventStreamBandwidth: "STREAM_BW",
+	EventHSDesc:          "HS_DESC",
+	EventGuard:           "GUARD",
+	EventNewConsensus:    "NEWCONSENSUS",
+}
+
+// String implements fmt.Stringer, returning the control protocol name of the
+// event type.
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("event(%d)", int(t))
+}
+
+// Event is an event delivered in-process by the embedded tor. The concrete types
+// are the *...Event structs of this package. String fields hold the keywords of
+// the control protocol event, empty if the field is absent.
+type Event interface {
+	Type() EventType
+}
+
+// Relay identifies a relay by its identity fingerprint and nickname.
+type Relay struct {
+	Fingerprint string // Hex encoded identity digest, without the leading '$'
+	Nickname    string // Nickname of the relay, empty if unknown
+}
+
+// parseRelay parses a relay's "$fingerprint~nickname" or "$fingerprint=nickname"
+// long name, accepting a bare nickname too.
+func parseRelay(name string) Relay {
+	if !strings.HasPrefix(name, "$") {
+		return Relay{Nickname: name}
+	}
+	name = name[1:]
+	if idx := strings.IndexAny(name, "~="); idx >= 0 {
+		return Relay{Fingerprint: name[:idx], Nickname: name[idx+1:]}
+	}
+	return Relay{Fingerprint: name}
+}
+
+// CircEvent reports a status change of a circuit originating from this tor.
+type CircEvent struct {
+	ID           uint64  // Global identifier of the circuit
+	Status       string  // LAUNCHED, BUILT, EXTENDED, FAILED or CLOSED
+	Path         []Relay // Hops of the circuit extended to so far
+	Purpose      string  // Purpose of the circuit (e.g. GENERAL or HS_CLIENT_REND)
+	Reason       string  // Reason for failing or closing the circuit
+	RemoteReason string  // Reason given by the remote side if it closed the circuit
+}
+
+// Type implements Event.
+func (e *CircEvent) Type() EventType { return EventCirc }
+
+// StreamEvent reports a status change of a client stream.
+type StreamEvent struct {
+	ID           uint64 // Global identifier of the stream
+	Status       string // NEW, NEWRESOLVE, REMAP, SENTCONNECT, SENTRESOLVE, SUCCEEDED, FAILED, CLOSED or DETACHED
+	CircID       uint64 // Circuit the stream is attached to, 0 if unattached
+	Target       string // Target address and port of the stream
+	Reason       string // Reason for failing, closing or detaching the stream
+	RemoteReason string // Reason given by the exit if it ended the stream
+	Source       string // Source of a remapped address: CACHE or EXIT
+	SourceAddr   string // Address and port of the client that opened a new stream
+	Purpose      string // Purpose of a new stream: DIR_FETCH, DIR_UPLOAD, DNS_REQUEST or USER
+}
+
+// Type implements Event.
+func (e *StreamEvent) Type() EventType { return EventStream }
+
+// ORConnEvent reports a status change of a connection to a relay.
+type ORConnEvent struct {
+	ID          uint64 // Global identifier of the connection
+	Target      string // Long name or address of the relay
+	Status      string // NEW, LAUNCHED, CONNECTED, FAILED or CLOSED
+	Reason      string // Reason for failing or closing the connection
+	NumCircuits int    // Number of circuits using or waiting for the connection
+}
+
+// Type implements Event.
+func (e *ORConnEvent) Type() EventType { return EventORConn }
+
+// BandwidthEvent reports the bytes tor transferred in the last second.
+type BandwidthEvent struct {
+	Read    uint64
+	Written uint64
+}
+
+// Type implements Event.
+func (e *BandwidthEvent) Type() EventType { return EventBandwidth }
+
+// StreamBandwidthEvent reports the bytes a stream transferred since the last such
+// event for the same stream.
+type StreamBandwidthEvent struct {
+	ID      uint64 // Global identifier of the stream
+	Read    uint64
+	Written uint64
+}
+
+// Type implements Event.
+func (e *StreamBandwidthEvent) Type() EventType { return EventStreamBandwidth }
+
+// HSDescEvent reports the progress of fetching or uploading an onion service
+// descriptor.
+type HSDescEvent struct {
+	Action   string // REQUESTED, UPLOAD, RECEIVED, UPLOADED, FAILED or CREATED
+	Address  string // Onion address without the .onion suffix, or UNKNOWN
+	AuthType string // Client authorization type, or UNKNOWN
+	HSDir    string // Long name of the directory contacted, or UNKNOWN
+	DescID   string // Identifier of the descriptor
+	Reason   string // Reason for a failure
+}
+
+// Type implements Event.
+func (e *HSDescEvent) Type() EventType { return EventHSDesc }
+
+// GuardEvent reports a status change of an entry guard.
+type GuardEvent struct {
+	Guard  Relay  // Entry guard whose status changed
+	Status string // NEW, UP, DOWN, BAD, GOOD, DROPPED
+}
+
+// Type implements Event.
+func (e *GuardEvent) Type() EventType { return EventGuard }
+
+// ConsensusEvent reports that tor switched to a new consensus. Queries issued in
+// reaction to the event already see the new consensus.
+type ConsensusEvent struct {
+	ValidAfter time.Time // Time the consensus became valid at
+	FreshUntil time.Time // Time the next consensus is expected by
+	ValidUntil time.Time // Time the consensus stops being usable at
+	Relays     int       // Number of relays listed in the consensus
+}
+
+// Type implements Event.
+func (e *ConsensusEvent) Type() EventType { return EventNewConsensus }
+
+// convertEvent converts an event of the embedded tor into its public counterpart.
+func convertEvent(event interface{}) Event {
+	switch ev := event.(type) {
+	case *libtor.CircEvent:
+		var path []Relay
+		if ev.Path != "" {
+			for _, hop := range strings.Split(ev.Path, ",") {
+				path = append(path, parseRelay(hop))
+			}
+		}
+		return &CircEvent{
+			ID:           ev.ID,
+			Status:       ev.Status,
+			Path:         path,
+			Purpose:      ev.Purpose,
+			Reason:       ev.Reason,
+			RemoteReason: ev.RemoteReason,
+		}
+	case *libtor.StreamEvent:
+		return &StreamEvent{
+			ID:           ev.ID,
+			Status:       ev.Status,
+			CircID:       ev.CircID,
+			Target:       ev.Target,
+			Reason:       ev.Reason,
+			RemoteReason: ev.RemoteReason,
+			Source:       ev.Source,
+			SourceAddr:   ev.SourceAddr,
+			Purpose:      ev.Purpose,
+		}
+	case *libtor.ORConnEvent:
+		return &ORConnEvent{
+			ID:          ev.ID,
+			Target:      ev.Target,
+			Status:      ev.Status,
+			Reason:      ev.Reason,
+			NumCircuits: ev.NumCircs,
+		}
+	case *libtor.BWEvent:
+		return &BandwidthEvent{Read: ev.Read, Written: ev.Written}
+
+	case *libtor.StreamBWEvent:
+		return &StreamBandwidthEvent{ID: ev.ID, Read: ev.Read, Written: ev.Written}
+
+	case *libtor.HSDescEvent:
+		return &HSDescEvent{
+			Action:   ev.Action,
+			Address:  ev.Address,
+			AuthType: ev.AuthType,
+			HSDir:    ev.HSDir,
+			DescID:   ev.DescID,
+			Reason:   ev.Reason,
+		}
+	case *libtor.GuardEvent:
+		return &GuardEvent{Guard: parseRelay(ev.Name), Status: ev.Status}
+
+	case *libtor.NewConsensusEvent:
+		return &ConsensusEvent{
+			ValidAfter: time.Unix(ev.ValidAfter, 0).UTC(),
+			FreshUntil: time.Unix(ev.FreshUntil, 0).UTC(),
+			ValidUntil: time.Unix(ev.ValidUntil, 0).UTC(),
+			Relays:     ev.Relays,
+		}
+	}
+	return nil
+}
+
+// eventBus fans out the events of the embedded tor to the subscriptions.
+type eventBus struct {
+	proc   embeddedProcess
+	subs   map[*EventSubscription]struct{}
+	closed bool
+	lock   sync.RWMutex
+}
+
+// EventSubscription is a registration for in-process events from the embedded
+// tor, delivering them on a channel.
+type EventSubscription struct {
+	dropped uint64 // Number of events dropped due to a full sink (atomic, first for 64 bit alignment)
+
+	bus     *eventBus
+	mask    uint32        // Bitmask of the event types to deliver
+	sink    chan<- Event  // Channel to deliver the events on
+	handler func(Event)   // Callback to deliver the events to instead of a sink
+	block   bool          // Whether to block tor until the sink accepts the events
+	quit    chan struct{} // Channel closed when the subscription is cancelled
+	once    sync.Once
+}
+
+// SubscribeEvents delivers the given types of events from the embedded tor on
+// the sink channel, until the subscription is closed or tor terminates. Events
+// are delivered straight from tor's main loop, without going through the control
+// protocol.
+//
+// If block is set, a full sink holds up tor (and the delivery to all the other
+// subscribers) until the event is accepted, pushing back on tor itself. This
+// ensures no event is lost, but a stalled subscriber stalls tor. Otherwise the
+// events not fitting into the sink are dropped and counted.
+//
+// The sink is never closed by the subscription.
+func (t *Tor) SubscribeEvents(sink chan<- Event, block bool, types ...EventType) (*EventSubscription, error) {
+	return t.events.subscribe(&EventSubscription{sink: sink, block: block}, types)
+}
+
+// subscribeFunc delivers the given types of events to a callback, invoked on
+// tor's main loop. The callback holds up tor until it returns, so it must not
+// block.
+func (b *eventBus) subscribeFunc(handler func(Event), types ...EventType) (*EventSubscription, error) {
+	return b.subscribe(&EventSubscription{handler: handler}, types)
+}
+
+// subscribe registers a subscription for the given event types on the bus.
+func (b *eventBus) subscribe(sub *EventSubscription, types []EventType) (*EventSubscription, error) {
+	sub.bus, sub.quit = b, make(chan struct{})
+	for _, kind := range types {
+		if _, ok := eventTypeNames[kind]; !ok {
+			return nil, fmt.Errorf("unknown event type: %v", kind)
+		}
+		sub.mask |= 1 << uint(kind)
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return nil, ErrTerminated
+	}
+	b.subs[sub] = struct{}{}
+	if err := b.updateMask(); err != nil {
+		delete(b.subs, sub)
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Dropped returns the number of events dropped so far because the sink of a non
+// blocking subscription was full.
+func (s *EventSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close cancels the subscription, stopping the delivery of events, and releasing
+// tor if it's blocked on the sink.
+func (s *EventSubscription) Close() {
+	s.once.Do(func() {
+		close(s.quit)
+
+		s.bus.lock.Lock()
+		defer s.bus.lock.Unlock()
+
+		delete(s.bus.subs, s)
+		if !s.bus.closed {
+			s.bus.updateMask()
+		}
+	})
+}
+
+// newEventBus creates an event bus hooked up to an embedded process, which must
+// not have been started yet.
+func newEventBus(proc embeddedProcess) (*eventBus, error) {
+	bus := &eventBus{
+		proc: proc,
+		subs: make(map[*EventSubscription]struct{}),
+	}
+	if err := proc.SetEventHandler(bus.deliver); err != nil {
+		return nil, err
+	}
+	return bus, nil
+}
+
+// updateMask requests tor to deliver the union of the event types the subscriptions
+// are interested in. The lock must be held.
+func (b *eventBus) updateMask() error {
+	var mask uint32
+	for sub := range b.subs {
+		mask |= sub.mask
+	}
+	return b.proc.SetEventMask(mask)
+}
+
+// deliver fans an event of the embedded tor out to the interested subscribers.
+// It runs on tor's main loop.
+func (b *eventBus) deliver(event interface{}) {
+	ev := convertEvent(event)
+	if ev == nil {
+		return
+	}
+	bit := uint32(1) << uint(ev.Type())
+
+	// Collect the subscribers, not holding the lock while blocking on any of them
+	b.lock.RLock()
+	var subs []*EventSubscription
+	for sub := range b.subs {
+		if sub.mask&bit != 0 {
+			subs = append(subs, sub)
+		}
+	}
+	b.lock.RUnlock()
+
+	for _, sub := range subs {
+		if sub.handler != nil {
+			sub.handler(ev)
+			continue
+		}
+		if sub.block {
+			select {
+			case sub.sink <- ev:
+			case <-sub.quit:
+			}
+			continue
+		}
+		select {
+		case sub.sink <- ev:
+		case <-sub.quit:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+}
+
+// close cancels all the subscriptions, releasing tor if it's blocked on any of
+// them, and rejects any new ones.
+func (b *eventBus) close() {
+	b.lock.Lock()
+	b.closed = true
+	subs := make([]*EventSubscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.lock.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
@@ -0,0 +1,208 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipsn/go-libtor/control"
+)
+
+// dedicatedLifetime is the time the circuit of exit constrained connections is
+// reused for after being built, matching tor's default MaxCircuitDirtiness.
+const dedicatedLifetime = 10 * time.Minute
+
+// ErrNoExit is returned when dialing with exit constraints that no relay in the
+// current consensus satisfies.
+var ErrNoExit = errors.New("no relay matches the exit constraints")
+
+// exitsKey is the context key of the per-call exit constraints.
+type exitsKey struct{}
+
+// ExitConstraints restricts the exit relays of the connections dialed with them.
+// Such connections are sent over dedicated circuits, built to an exit satisfying
+// the constraints and shared only by connections with the same constraints and
+// isolation key. The constraints don't apply to onion services.
+//
+// Relays excluded via ExcludeNodes or ExcludeExitNodes in the configuration are
+// never used, but ExitNodes is overridden.
+type ExitConstraints struct {
+	Countries []string // Two-letter country codes the exit must be located in, any if empty
+	Exits     []string // Fingerprints or nicknames the exit must be one of, any if empty
+
+	ExcludeCountries []string // Two-letter country codes the exit must not be located in
+	ExcludeRelays    []string // Fingerprints, nicknames or addresses never to exit from
+}
+
+// WithExitConstraints returns a context that makes the connections dialed with it
+// exit from relays satisfying the constraints.
+func WithExitConstraints(ctx context.Context, exits *ExitConstraints) context.Context {
+	return context.WithValue(ctx, exitsKey{}, exits)
+}
+
+// exitConstraints returns the exit constraints of a context, nil if none is set.
+func exitConstraints(ctx context.Context) *ExitConstraints {
+	exits, _ := ctx.Value(exitsKey{}).(*ExitConstraints)
+	return exits
+}
+
+// args converts the constraints into the EXTENDCIRCUIT arguments choosing an exit
+// for the given port, each a routerset in torrc syntax.
+func (c *ExitConstraints) args(port int) (string, error) {
+	include, err := routerset(c.Countries, c.Exits)
+	if err != nil {
+		return "", err
+	}
+	exclude, err := routerset(c.ExcludeCountries, c.ExcludeRelays)
+	if err != nil {
+		return "", err
+	}
+	args := fmt.Sprintf("EXITPORT=%d", port)
+	if len(include) > 0 {
+		args += " EXITNODES=" + strings.Join(include, ",")
+	}
+	if len(exclude) > 0 {
+		args += " EXCLUDENODES=" + strings.Join(exclude, ",")
+	}
+	return args, nil
+}
+
+// key returns an identifier of the constraints, equal for equivalent ones.
+func (c *ExitConstraints) key() string {
+	norm := func(items []string) string {
+		items = append([]string(nil), items...)
+		for i, item := range items {
+			items[i] = strings.ToLower(strings.TrimPrefix(item, "$"))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	}
+	return norm(c.Countries) + "/" + norm(c.Exits) + "/" + norm(c.ExcludeCountries) + "/" + norm(c.ExcludeRelays)
+}
+
+// routerset assembles the entries of a routerset in torrc syntax from country
+// codes and relays, validating them.
+func routerset(countries []string, relays []string) ([]string, error) {
+	var set []string
+	for _, country := range countries {
+		if len(country) != 2 {
+			return nil, fmt.Errorf("invalid country code: %q", country)
+		}
+		set = append(set, "{"+strings.ToLower(country)+"}")
+	}
+	for _, relay := range relays {
+		if err := validateRouter(relay); err != nil {
+			return nil, err
+		}
+		set = append(set, relay)
+	}
+	return set, nil
+}
+
+// dedicatedCircuit is a circuit built for the connections with the same exit
+// constraints, target port and isolation key.
+type dedicatedCircuit struct {
+	build   *circuitBuild // Launch of the circuit, tracking its outcome
+	waiters int           // Number of dials waiting for the circuit to be built
+	used    bool          // Whether any connection was pinned to the circuit
+}
+
+// exitCircuit returns the dedicated circuit of the connections with the given
+// exit constraints, target port and isolation key, building one if none is open.
+// Streams pinned to it are attached by the tracker, so the dial must be pinned.
+func (t *connTracker) exitCircuit(ctx context.Context, exits *ExitConstraints, port int, isolation string) (uint64, error) {
+	args, err := exits.args(port)
+	if err != nil {
+		return 0, err
+	}
+	key := exits.key() + "|" + strconv.Itoa(port) + "|" + isolation
+
+	// Join the build of the current circuit if any, or start a new one
+	t.lock.Lock()
+	circ := t.dedicated[key]
+	if circ != nil && !circ.build.built.IsZero() && time.Since(circ.build.built) > dedicatedLifetime {
+		delete(t.dedicated, key)
+		go t.closeCircuit(circ.build.id)
+		circ = nil
+	}
+	launch := circ == nil
+	if launch {
+		circ = &dedicatedCircuit{build: &circuitBuild{done: make(chan struct{})}}
+		t.dedicated[key] = circ
+	}
+	circ.waiters++
+	t.lock.Unlock()
+
+	if launch {
+		if err := t.launch(circ.build, "0 purpose=controller "+args); err != nil {
+			if cerr, ok := err.(*control.Error); ok && cerr.Code == 551 && strings.HasPrefix(cerr.Message, "No relay matches") {
+				err = ErrNoExit
+			}
+			t.lock.Lock()
+			if t.dedicated[key] == circ {
+				delete(t.dedicated, key)
+			}
+			circ.build.err = err
+			close(circ.build.done)
+			t.lock.Unlock()
+		}
+	}
+	select {
+	case <-circ.build.done:
+	case <-ctx.Done():
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	circ.waiters--
+	if ctx.Err() != nil {
+		// Don't leave unused circuits around, tor never expires them
+		if circ.waiters == 0 && !circ.used {
+			if t.dedicated[key] == circ {
+				delete(t.dedicated, key)
+			}
+			if circ.build.id != 0 {
+				go t.closeCircuit(circ.build.id)
+			}
+		}
+		return 0, ctx.Err()
+	}
+	if circ.build.err != nil {
+		return 0, circ.build.err
+	}
+	circ.used = true
+	return circ.build.id, nil
+}
+
+// retire drops a closed circuit from the dedicated ones.
+func (t *connTracker) retire(id uint64) {
+	for key, circ := range t.dedicated {
+		if circ.build.id == id {
+			delete(t.dedicated, key)
+		}
+	}
+}
+
+// closeCircuit closes a circuit once it has no streams left.
+func (t *connTracker) closeCircuit(id uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+
+	t.ctrl.CloseCircuit(ctx, strconv.FormatUint(id, 10), true)
+}
+
+// validateRouter checks that a relay (or address) can be passed to tor within a
+// routerset without breaking up the control command.
+func validateRouter(router string) error {
+	if router == "" || strings.ContainsAny(router, " \t\r\n,\"{}") {
+		return fmt.Errorf("invalid relay: %q", router)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import "testing"
+
+// Tests that exit constraints are converted into EXTENDCIRCUIT routersets, with
+// countries and relays kept apart even if a nickname looks like a country code.
+func TestExitConstraintsArgs(t *testing.T) {
+	tests := []struct {
+		exits *ExitConstraints
+		args  string
+		fail  bool
+	}{
+		{exits: &ExitConstraints{}, args: "EXITPORT=443"},
+		{
+			exits: &ExitConstraints{Countries: []string{"DE", "nl"}, Exits: []string{"$0011223344556677889900112233445566778899"}},
+			args:  "EXITPORT=443 EXITNODES={de},{nl},$0011223344556677889900112233445566778899",
+		},
+		{
+			exits: &ExitConstraints{ExcludeCountries: []string{"US"}, ExcludeRelays: []string{"ab", "10.0.0.0/8"}},
+			args:  "EXITPORT=443 EXCLUDENODES={us},ab,10.0.0.0/8",
+		},
+		{exits: &ExitConstraints{Countries: []string{"deu"}}, fail: true},
+		{exits: &ExitConstraints{ExcludeCountries: []string{"d"}}, fail: true},
+		{exits: &ExitConstraints{Exits: []string{"a,b"}}, fail: true},
+		{exits: &ExitConstraints{ExcludeRelays: []string{"{us}"}}, fail: true},
+		{exits: &ExitConstraints{ExcludeRelays: []string{""}}, fail: true},
+	}
+	for i, tt := range tests {
+		args, err := tt.exits.args(443)
+		switch {
+		case tt.fail && err == nil:
+			t.Errorf("test %d: invalid constraints accepted: %q", i, args)
+		case !tt.fail && err != nil:
+			t.Errorf("test %d: valid constraints rejected: %v", i, err)
+		case !tt.fail && args != tt.args:
+			t.Errorf("test %d: arguments mismatch: have %q, want %q", i, args, tt.args)
+		}
+	}
+}
+
+// Tests that excluded countries and relays are told apart by the constraint keys.
+func TestExitConstraintsKey(t *testing.T) {
+	countries := &ExitConstraints{ExcludeCountries: []string{"ab"}}
+	relays := &ExitConstraints{ExcludeRelays: []string{"ab"}}
+	if countries.key() == relays.key() {
+		t.Errorf("excluded country and relay share key %q", countries.key())
+	}
+	a := &ExitConstraints{Countries: []string{"DE", "nl"}, ExcludeRelays: []string{"$AABB"}}
+	b := &ExitConstraints{Countries: []string{"nl", "de"}, ExcludeRelays: []string{"aabb"}}
+	if a.key() != b.key() {
+		t.Errorf("key mismatch of equivalent constraints: %q != %q", a.key(), b.key())
+	}
+}
@@ -5,5 +5,5 @@ go 1.12
 require (
 	github.com/cretz/bine v0.1.0
 	github.com/stretchr/testify v1.3.0 // indirect
-	golang.org/x/crypto v0.0.0-20190404164418-38d8ce5564a5 // indirect
+	golang.org/x/crypto v0.0.0-20190404164418-38d8ce5564a5
 )
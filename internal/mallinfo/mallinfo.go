@@ -0,0 +1,40 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+// Package mallinfo reports the memory allocated through the C heap, to check
+// the embedded tor for leaks across restarts.
+package mallinfo
+
+/*
+#include <stdlib.h>
+
+#ifdef __GLIBC__
+#include <malloc.h>
+
+// heapInUse returns the bytes allocated via malloc and not yet freed, summed
+// over all arenas, including the chunks that were mmapped directly.
+static long long heapInUse() {
+#if __GLIBC__ > 2 || (__GLIBC__ == 2 && __GLIBC_MINOR__ >= 33)
+	struct mallinfo2 mi = mallinfo2();
+#else
+	struct mallinfo mi = mallinfo();
+#endif
+	return (long long)mi.uordblks + (long long)mi.hblkhd;
+}
+#else
+static long long heapInUse() {
+	return -1;
+}
+#endif
+*/
+import "C"
+
+// InUse returns the number of bytes currently allocated from the C heap, or
+// false if the C library can't report it (e.g. musl).
+func InUse() (uint64, bool) {
+	n := C.heapInUse()
+	if n < 0 {
+		return 0, false
+	}
+	return uint64(n), true
+}
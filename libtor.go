@@ -21,4 +21,7 @@ func ProviderVersion() string {
 
 // Creator implements the bine.process.Creator, permitting libtor to act as an API
 // backend for the bine/tor Go interface.
+//
+// Creator is retained for bine compatibility, Start provides a native Go API to
+// manage an embedded tor instance without depending on bine.
 var Creator process.Creator = libtor.Creator
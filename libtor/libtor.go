@@ -8,7 +8,11 @@ package libtor
 
 /*
 #include <stdlib.h>
+#include <sys/stat.h>
 #include <tor_api.h>
+#include <core/or/or.h>
+#include <app/config/config.h>
+#include <feature/api/tor_api_internal.h>
 
 static char** makeCharArray(int size) {
 	return calloc(sizeof(char*), size);
@@ -22,14 +26,65 @@ static void freeCharArray(char **a, int size) {
 		free(a[i]);
 	free(a);
 }
+
+// logCallback is the Go handler of tor's log messages, exported from Go.
+extern void logCallback(int severity, unsigned int domain, char *msg);
+
+static void logTrampoline(int severity, unsigned int domain, const char *msg) {
+	logCallback(severity, domain, (char *)msg);
+}
+static int setLogCallback(tor_main_configuration_t *cfg, const char *severity) {
+	return tor_main_configuration_set_log_callback(cfg, logTrampoline, severity);
+}
+
+// eventCallback is the Go handler of tor's events, exported from Go.
+extern void eventCallback(int type, void *event);
+
+static void eventTrampoline(int type, const void *event) {
+	eventCallback(type, (void *)event);
+}
+static int setEventCallback(tor_main_configuration_t *cfg) {
+	return tor_main_configuration_set_event_callback(cfg, eventTrampoline);
+}
+
+// pathCallback is the Go handler of tor's path selection, exported from Go.
+extern void pathCallback(int position, char *purpose, void *candidates, int n);
+
+static void pathTrampoline(int position, const char *purpose, tor_path_candidate_t *candidates, int n) {
+	pathCallback(position, (char *)purpose, (void *)candidates, n);
+}
+static int setPathCallback(tor_main_configuration_t *cfg) {
+	return tor_main_configuration_set_path_callback(cfg, pathTrampoline);
+}
+
+// controlSocketInode returns the inode backing the tor side of the owning control
+// socket, or 0 if there's no such socket open.
+static unsigned long long controlSocketInode(tor_main_configuration_t *cfg) {
+	struct stat st;
+	if (!SOCKET_OK(cfg->owning_controller_socket) || fstat(cfg->owning_controller_socket, &st) < 0)
+		return 0;
+	return st.st_ino;
+}
+
+// releaseConfiguration frees a tor configuration after tor_run_main returned.
+// If tor adopted the owning control socket, it already closed it during cleanup
+// and the descriptor might have been reused since, so it must not be closed again.
+static void releaseConfiguration(tor_main_configuration_t *cfg, unsigned long long ino) {
+	if (controlSocketInode(cfg) != ino)
+		cfg->owning_controller_socket = TOR_INVALID_SOCKET;
+	tor_main_configuration_free(cfg);
+}
 */
 import "C"
 import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"sync"
+	"unsafe"
 
 	"github.com/cretz/bine/process"
 )
@@ -42,8 +97,17 @@ func ProviderVersion() string {
 
 // Creator implements the bine.process.Creator, permitting libtor to act as an API
 // backend for the bine/tor Go interface.
+//
+// Tor keeps its state in globals, so only one embedded process may run at any
+// given time. Processes may however be started sequentially, one after another
+// has terminated.
 var Creator process.Creator = new(embeddedCreator)
 
+var (
+	instanceLock    sync.Mutex // Lock protecting the running instance flag
+	instanceRunning bool       // Flag whether an embedded tor is currently running
+)
+
 // embeddedCreator implements process.Creator, permitting libtor to act as an API
 // backend for the bine/tor Go interface.
 type embeddedCreator struct{}
@@ -66,16 +130,50 @@ type embeddedProcess struct {
 	ctx  context.Context
 	conf *C.struct_tor_main_configuration_t
 	args []string
-	done chan int
+
+	owner  io.Closer                                     // Owning controller connection, closing it terminates tor
+	logger func(severity int, domain uint32, msg string) // Handler to deliver tor's log messages to
+	events func(event interface{})                       // Handler to deliver tor's events to
+	paths  func(int, string, []PathCandidate)            // Handler to consult when choosing circuit paths
+	done   chan struct{}                                 // Channel closed when the embedded tor terminates
+	code   int                                           // Exit code of the embedded tor, valid after done is closed
+
+	lock sync.Mutex
 }
 
 // Start implements process.Process, starting up the libtor embedded process.
 func (e *embeddedProcess) Start() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
 	if e.done != nil {
 		return errors.New("already started")
 	}
-	// Create the char array for the args
-	args := append([]string{"tor"}, e.args...)
+	if e.conf == nil {
+		return errors.New("already closed")
+	}
+	// Tor cannot run multiple instances concurrently, make sure we're alone
+	instanceLock.Lock()
+	defer instanceLock.Unlock()
+
+	if instanceRunning {
+		return errors.New("another embedded tor is already running")
+	}
+	// Ensure there's an owning controller connection to shut tor down with. If
+	// the user requested one, that's good enough, otherwise create a private one.
+	if e.owner == nil {
+		fd := C.tor_main_configuration_setup_control_socket(e.conf)
+		if fd == C.INVALID_TOR_CONTROL_SOCKET {
+			return errors.New("unable to create owning control socket")
+		}
+		e.owner = os.NewFile(uintptr(fd), "")
+	}
+	// Create the char array for the args, silencing tor if it logs into Go
+	args := []string{"tor"}
+	if e.logger != nil {
+		args = append(args, "--quiet")
+	}
+	args = append(args, e.args...)
 
 	charArray := C.makeCharArray(C.int(len(args)))
 	for i, a := range args {
@@ -85,42 +183,336 @@ func (e *embeddedProcess) Start() error {
 	if code := C.tor_main_configuration_set_command_line(e.conf, C.int(len(args)), charArray); code != 0 {
 		C.tor_main_configuration_free(e.conf)
 		C.freeCharArray(charArray, C.int(len(args)))
+		e.conf = nil
+		e.owner.Close()
 		return fmt.Errorf("failed to set arguments: %v", int(code))
 	}
-	// Start tor and return
-	e.done = make(chan int, 1)
+	// Start tor and return. Tor tears down all its global state at the end of
+	// tor_run_main, so once it returns, a new instance can be started.
+	conf, done := e.conf, make(chan struct{})
+	e.done = done
+
+	instanceRunning = true
+	setLogHandler(e.logger)
+	setEventHandler(e.events)
+	setEventMask(0)
+	setPathHandler(e.paths)
+	setPathEnabled(false)
+
 	go func() {
+		defer close(done)
+		defer func() {
+			instanceLock.Lock()
+			instanceRunning = false
+			setLogHandler(nil)
+			setEventHandler(nil)
+			setEventMask(0)
+			setPathHandler(nil)
+			setPathEnabled(false)
+			instanceLock.Unlock()
+		}()
 		defer C.freeCharArray(charArray, C.int(len(args)))
-		defer C.tor_main_configuration_free(e.conf)
-		e.done <- int(C.tor_run_main(e.conf))
+
+		inode := C.controlSocketInode(conf)
+		e.code = int(C.tor_run_main(conf))
+		C.releaseConfiguration(conf, inode)
+	}()
+	// Tear tor down cleanly if the context is cancelled
+	go func() {
+		select {
+		case <-e.ctx.Done():
+			e.shutdown()
+		case <-done:
+		}
 	}()
 	return nil
 }
 
-// Wait implements process.Process, blocking until the embedded process terminates.
-func (e *embeddedProcess) Wait() error {
+// SetTorrc configures the contents of the torrc and the defaults torrc to start
+// the embedded tor with, instead of reading them from disk.
+func (e *embeddedProcess) SetTorrc(torrc, defaults string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.conf == nil || e.done != nil {
+		return errors.New("already started")
+	}
+	ctorrc, cdefaults := C.CString(torrc), C.CString(defaults)
+	defer C.free(unsafe.Pointer(ctorrc))
+	defer C.free(unsafe.Pointer(cdefaults))
+
+	if code := C.tor_main_configuration_set_torrc(e.conf, ctorrc, cdefaults); code != 0 {
+		return fmt.Errorf("failed to set torrc: %v", int(code))
+	}
+	return nil
+}
+
+// SetLogHandler configures the embedded tor to deliver its log messages to the
+// given handler, from the very first one, instead of printing them to stdout.
+// The severity selects the messages to deliver, in the format of the severities
+// of tor's Log option (e.g. "[handshake]debug notice").
+//
+// The handler is invoked synchronously from tor's threads, so it should return
+// quickly and must not block on tor.
+func (e *embeddedProcess) SetLogHandler(handler func(severity int, domain uint32, msg string), severity string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.conf == nil || e.done != nil {
+		return errors.New("already started")
+	}
+	cseverity := C.CString(severity)
+	defer C.free(unsafe.Pointer(cseverity))
+
+	if code := C.setLogCallback(e.conf, cseverity); code != 0 {
+		return fmt.Errorf("failed to set log callback: %v", int(code))
+	}
+	e.logger = handler
+	return nil
+}
+
+// SetEventHandler configures the embedded tor to deliver its events to the given
+// handler, bypassing the control protocol. No events are delivered until they
+// are selected with SetEventMask.
+//
+// The handler is invoked synchronously from tor's main loop, blocking tor until
+// it returns. It must not block on tor.
+func (e *embeddedProcess) SetEventHandler(handler func(event interface{})) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.conf == nil || e.done != nil {
+		return errors.New("already started")
+	}
+	if code := C.setEventCallback(e.conf); code != 0 {
+		return fmt.Errorf("failed to set event callback: %v", int(code))
+	}
+	e.events = handler
+	return nil
+}
+
+// SetEventMask selects the event types to deliver to the event handler of the
+// running embedded tor, as a bitmask of 1<<Event* values.
+func (e *embeddedProcess) SetEventMask(mask uint32) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
 	if e.done == nil {
 		return errors.New("not started")
 	}
 	select {
-	case <-e.ctx.Done():
-		return e.ctx.Err()
+	case <-e.done:
+		return errors.New("already terminated")
+	default:
+	}
+	setEventMask(mask)
+	return nil
+}
 
-	case code := <-e.done:
-		if code == 0 {
-			return nil
+// SetPathHandler configures the embedded tor to consult the given handler when
+// choosing the relays of new circuits, letting it change the weights of the
+// candidates or veto them. It is not consulted until enabled with EnablePaths.
+//
+// The handler is invoked synchronously from tor's main loop, blocking tor until
+// it returns. It must not block on tor.
+func (e *embeddedProcess) SetPathHandler(handler func(position int, purpose string, candidates []PathCandidate)) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.conf == nil || e.done != nil {
+		return errors.New("already started")
+	}
+	if code := C.setPathCallback(e.conf); code != 0 {
+		return fmt.Errorf("failed to set path callback: %v", int(code))
+	}
+	e.paths = handler
+	return nil
+}
+
+// EnablePaths enables or disables consulting the path handler of the running
+// embedded tor.
+func (e *embeddedProcess) EnablePaths(enabled bool) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.done == nil {
+		return errors.New("not started")
+	}
+	select {
+	case <-e.done:
+		return errors.New("already terminated")
+	default:
+	}
+	setPathEnabled(enabled)
+	return nil
+}
+
+// VerifyConfig runs tor's command line and configuration file parsing and its
+// option validation, without actually starting tor. The returned message is
+// tor's reason for rejecting the configuration, or empty if it was accepted.
+//
+// The process is consumed by the verification, it cannot be started afterwards.
+func (e *embeddedProcess) VerifyConfig() (string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.done != nil {
+		return "", errors.New("already started")
+	}
+	if e.conf == nil {
+		return "", errors.New("already closed")
+	}
+	// Verification runs through the full tor startup, so it must be alone too
+	instanceLock.Lock()
+	if instanceRunning {
+		instanceLock.Unlock()
+		return "", errors.New("another embedded tor is already running")
+	}
+	instanceRunning = true
+	setLogHandler(e.logger)
+	instanceLock.Unlock()
+
+	defer func() {
+		instanceLock.Lock()
+		instanceRunning = false
+		setLogHandler(nil)
+		instanceLock.Unlock()
+	}()
+	conf := e.conf
+	e.conf = nil
+	defer C.tor_main_configuration_free(conf)
+
+	if e.owner != nil {
+		defer e.owner.Close()
+	}
+	// Create the char array for the args, requesting verification only. Tor is
+	// silenced, the rejection reason is returned instead.
+	args := append([]string{"tor", "--verify-config", "--quiet"}, e.args...)
+
+	charArray := C.makeCharArray(C.int(len(args)))
+	for i, a := range args {
+		C.setArrayString(charArray, C.CString(a), C.int(i))
+	}
+	defer C.freeCharArray(charArray, C.int(len(args)))
+
+	if code := C.tor_main_configuration_set_command_line(conf, C.int(len(args)), charArray); code != 0 {
+		return "", fmt.Errorf("failed to set arguments: %v", int(code))
+	}
+	code := C.tor_run_main(conf)
+
+	// Retrieve the rejection reason if tor reported any
+	if msg := C.options_take_last_error(); msg != nil {
+		defer C.free(unsafe.Pointer(msg))
+		return C.GoString(msg), nil
+	}
+	if code != 0 {
+		return fmt.Sprintf("configuration rejected (exit code %d)", int(code)), nil
+	}
+	return "", nil
+}
+
+// Wait implements process.Process, blocking until the embedded process terminates.
+func (e *embeddedProcess) Wait() error {
+	e.lock.Lock()
+	done := e.done
+	e.lock.Unlock()
+
+	if done == nil {
+		return errors.New("not started")
+	}
+	<-done
+
+	if e.code == 0 {
+		return nil
+	}
+	return fmt.Errorf("embedded tor failed: %v", e.code)
+}
+
+// Close implements io.Closer, requesting the embedded process to shut down the
+// same way a SIGTERM would and blocking until it terminates. The returned error
+// is the exit status of the embedded tor.
+//
+// Closing a process that was never started releases its configuration.
+func (e *embeddedProcess) Close() error {
+	e.lock.Lock()
+	if e.done == nil {
+		if e.conf != nil {
+			C.tor_main_configuration_free(e.conf)
+			e.conf = nil
 		}
-		return fmt.Errorf("embedded tor failed: %v", code)
+		e.lock.Unlock()
+		return nil
 	}
+	e.lock.Unlock()
+
+	e.shutdown()
+	return e.Wait()
+}
+
+// shutdown signals the embedded tor to terminate by closing its owning controller
+// connection. Tor will notice it on its own event loop, shut down its listeners
+// and workers and return from tor_run_main.
+func (e *embeddedProcess) shutdown() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.owner != nil {
+		e.owner.Close()
+	}
+}
+
+// SocksConn creates a new client connection to the running embedded tor, without
+// any listener involved. Tor handles it as if its SocksPort had just accepted it,
+// so the returned connection speaks SOCKS.
+//
+// The returned identifier is reported as the "<socketpair:ID>" source address of
+// the connection's streams.
+func (e *embeddedProcess) SocksConn() (net.Conn, uint64, error) {
+	e.lock.Lock()
+	done := e.done
+	e.lock.Unlock()
+
+	if done == nil {
+		return nil, 0, errors.New("not started")
+	}
+	var id C.ulonglong
+	fd := C.tor_api_new_socks_connection(&id)
+	if fd == C.INVALID_TOR_CONTROL_SOCKET {
+		return nil, 0, errors.New("embedded tor not running")
+	}
+	file := os.NewFile(uintptr(fd), "")
+	defer file.Close()
+
+	conn, err := net.FileConn(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to create socks socket: %v", err)
+	}
+	return conn, uint64(id), nil
 }
 
 // EmbeddedControlConn implements process.Process, connecting to the control port
 // of the embedded Tor isntance.
+//
+// The returned connection is the owning controller of the embedded tor, closing
+// it will shut the process down.
 func (e *embeddedProcess) EmbeddedControlConn() (net.Conn, error) {
-	file := os.NewFile(uintptr(C.tor_main_configuration_setup_control_socket(e.conf)), "")
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.conf == nil || e.done != nil {
+		return nil, errors.New("already started")
+	}
+	fd := C.tor_main_configuration_setup_control_socket(e.conf)
+	if fd == C.INVALID_TOR_CONTROL_SOCKET {
+		return nil, errors.New("control socket already created")
+	}
+	file := os.NewFile(uintptr(fd), "")
+	defer file.Close()
+
 	conn, err := net.FileConn(file)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create control socket: %v", err)
 	}
+	e.owner = conn
 	return conn, nil
 }
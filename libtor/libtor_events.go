@@ -0,0 +1,198 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+// #include <tor_api.h>
+import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// Event types delivered by the embedded tor, also the bit positions of the mask
+// selecting them.
+const (
+	EventCirc         = C.TOR_EVENT_CIRC
+	EventStream       = C.TOR_EVENT_STREAM
+	EventORConn       = C.TOR_EVENT_ORCONN
+	EventBW           = C.TOR_EVENT_BW
+	EventStreamBW     = C.TOR_EVENT_STREAM_BW
+	EventHSDesc       = C.TOR_EVENT_HS_DESC
+	EventGuard        = C.TOR_EVENT_GUARD
+	EventNewConsensus = C.TOR_EVENT_NEWCONSENSUS
+)
+
+// CircEvent is a CIRC event of the embedded tor, with absent fields left empty.
+type CircEvent struct {
+	ID           uint64
+	Status       string
+	Path         string
+	Purpose      string
+	Reason       string
+	RemoteReason string
+}
+
+// StreamEvent is a STREAM event of the embedded tor.
+type StreamEvent struct {
+	ID           uint64
+	Status       string
+	CircID       uint64
+	Target       string
+	Reason       string
+	RemoteReason string
+	Source       string
+	SourceAddr   string
+	Purpose      string
+}
+
+// ORConnEvent is an ORCONN event of the embedded tor.
+type ORConnEvent struct {
+	ID       uint64
+	Target   string
+	Status   string
+	Reason   string
+	NumCircs int
+}
+
+// BWEvent is a BW event of the embedded tor.
+type BWEvent struct {
+	Read    uint64
+	Written uint64
+}
+
+// StreamBWEvent is a STREAM_BW event of the embedded tor.
+type StreamBWEvent struct {
+	ID      uint64
+	Read    uint64
+	Written uint64
+}
+
+// HSDescEvent is an HS_DESC event of the embedded tor.
+type HSDescEvent struct {
+	Action   string
+	Address  string
+	AuthType string
+	HSDir    string
+	DescID   string
+	Reason   string
+}
+
+// GuardEvent is a GUARD event of the embedded tor.
+type GuardEvent struct {
+	Name   string
+	Status string
+}
+
+// NewConsensusEvent is a NEWCONSENSUS event of the embedded tor, reduced to the
+// summary of the consensus, with times in seconds since the Unix epoch.
+type NewConsensusEvent struct {
+	ValidAfter int64
+	FreshUntil int64
+	ValidUntil int64
+	Relays     int
+}
+
+var (
+	eventHandler func(event interface{}) // Event handler of the running instance
+	eventLock    sync.RWMutex            // Lock protecting the event handler
+)
+
+// setEventHandler replaces the event handler of the running tor instance.
+func setEventHandler(handler func(event interface{})) {
+	eventLock.Lock()
+	defer eventLock.Unlock()
+
+	eventHandler = handler
+}
+
+// setEventMask selects the event types tor delivers to the event handler.
+func setEventMask(mask uint32) {
+	C.tor_set_event_mask(C.uint(mask))
+}
+
+// eventCallback is invoked by tor from its main loop for every event selected
+// by the event mask, converting it to its Go counterpart. Absent fields are NULL
+// in C and empty in Go.
+//
+//export eventCallback
+func eventCallback(kind C.int, event unsafe.Pointer) {
+	eventLock.RLock()
+	handler := eventHandler
+	eventLock.RUnlock()
+
+	if handler == nil {
+		return
+	}
+	switch kind {
+	case EventCirc:
+		ev := (*C.tor_circ_event_t)(event)
+		handler(&CircEvent{
+			ID:           uint64(ev.id),
+			Status:       C.GoString(ev.status),
+			Path:         C.GoString(ev.path),
+			Purpose:      C.GoString(ev.purpose),
+			Reason:       C.GoString(ev.reason),
+			RemoteReason: C.GoString(ev.remote_reason),
+		})
+	case EventStream:
+		ev := (*C.tor_stream_event_t)(event)
+		handler(&StreamEvent{
+			ID:           uint64(ev.id),
+			Status:       C.GoString(ev.status),
+			CircID:       uint64(ev.circ_id),
+			Target:       C.GoString(ev.target),
+			Reason:       C.GoString(ev.reason),
+			RemoteReason: C.GoString(ev.remote_reason),
+			Source:       C.GoString(ev.source),
+			SourceAddr:   C.GoString(ev.source_addr),
+			Purpose:      C.GoString(ev.purpose),
+		})
+	case EventORConn:
+		ev := (*C.tor_orconn_event_t)(event)
+		handler(&ORConnEvent{
+			ID:       uint64(ev.id),
+			Target:   C.GoString(ev.target),
+			Status:   C.GoString(ev.status),
+			Reason:   C.GoString(ev.reason),
+			NumCircs: int(ev.ncircs),
+		})
+	case EventBW:
+		ev := (*C.tor_bw_event_t)(event)
+		handler(&BWEvent{
+			Read:    uint64(ev.read),
+			Written: uint64(ev.written),
+		})
+	case EventStreamBW:
+		ev := (*C.tor_stream_bw_event_t)(event)
+		handler(&StreamBWEvent{
+			ID:      uint64(ev.id),
+			Read:    uint64(ev.read),
+			Written: uint64(ev.written),
+		})
+	case EventHSDesc:
+		ev := (*C.tor_hs_desc_event_t)(event)
+		handler(&HSDescEvent{
+			Action:   C.GoString(ev.action),
+			Address:  C.GoString(ev.address),
+			AuthType: C.GoString(ev.auth_type),
+			HSDir:    C.GoString(ev.hsdir),
+			DescID:   C.GoString(ev.desc_id),
+			Reason:   C.GoString(ev.reason),
+		})
+	case EventGuard:
+		ev := (*C.tor_guard_event_t)(event)
+		handler(&GuardEvent{
+			Name:   C.GoString(ev.name),
+			Status: C.GoString(ev.status),
+		})
+	case EventNewConsensus:
+		ev := (*C.tor_newconsensus_event_t)(event)
+		handler(&NewConsensusEvent{
+			ValidAfter: int64(ev.valid_after),
+			FreshUntil: int64(ev.fresh_until),
+			ValidUntil: int64(ev.valid_until),
+			Relays:     int(ev.n_relays),
+		})
+	}
+}
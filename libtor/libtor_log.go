@@ -0,0 +1,38 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+// #include <stdlib.h>
+import "C"
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	logHandler func(severity int, domain uint32, msg string) // Log handler of the running instance
+	logLock    sync.RWMutex                                  // Lock protecting the log handler
+)
+
+// setLogHandler replaces the log handler of the running tor instance.
+func setLogHandler(handler func(severity int, domain uint32, msg string)) {
+	logLock.Lock()
+	defer logLock.Unlock()
+
+	logHandler = handler
+}
+
+// logCallback is invoked by tor for every log message it emits, on whichever
+// thread the message was logged from.
+//
+//export logCallback
+func logCallback(severity C.int, domain C.uint, msg *C.char) {
+	logLock.RLock()
+	handler := logHandler
+	logLock.RUnlock()
+
+	if handler != nil {
+		handler(int(severity), uint32(domain), strings.TrimSuffix(C.GoString(msg), "\n"))
+	}
+}
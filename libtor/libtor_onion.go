@@ -0,0 +1,52 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+// #include <stdlib.h>
+// #include <tor_api.h>
+import "C"
+import "unsafe"
+
+// Results of the onion address helpers.
+const (
+	OnionOK          = C.TOR_ONION_OK
+	OnionMalformed   = C.TOR_ONION_MALFORMED
+	OnionBadChecksum = C.TOR_ONION_BAD_CHECKSUM
+	OnionBadVersion  = C.TOR_ONION_BAD_VERSION
+	OnionBadKey      = C.TOR_ONION_BAD_KEY
+)
+
+// OnionBuildAddress builds the v3 onion address (without the .onion suffix) of
+// a 32 byte ed25519 identity key.
+func OnionBuildAddress(pubkey []byte) (string, int) {
+	var address [C.TOR_ONION_ADDRESS_LEN + 1]C.char
+
+	if res := C.tor_onion_build_address((*C.uchar)(unsafe.Pointer(&pubkey[0])), &address[0]); res != OnionOK {
+		return "", int(res)
+	}
+	return C.GoString(&address[0]), OnionOK
+}
+
+// OnionParseAddress parses and validates a lowercase v3 onion address (without
+// the .onion suffix), returning the ed25519 identity key it encodes.
+func OnionParseAddress(address string) ([]byte, int) {
+	caddress := C.CString(address)
+	defer C.free(unsafe.Pointer(caddress))
+
+	pubkey := make([]byte, C.TOR_ONION_KEY_LEN)
+	if res := C.tor_onion_parse_address(caddress, (*C.uchar)(unsafe.Pointer(&pubkey[0]))); res != OnionOK {
+		return nil, int(res)
+	}
+	return pubkey, OnionOK
+}
+
+// OnionBlindKey blinds a 32 byte ed25519 identity key for a time period of the
+// given length in minutes.
+func OnionBlindKey(pubkey []byte, period uint64, length uint64) ([]byte, int) {
+	blinded := make([]byte, C.TOR_ONION_KEY_LEN)
+	if res := C.tor_onion_blind_pubkey((*C.uchar)(unsafe.Pointer(&pubkey[0])), C.ulonglong(period), C.ulonglong(length), (*C.uchar)(unsafe.Pointer(&blinded[0]))); res != OnionOK {
+		return nil, int(res)
+	}
+	return blinded, OnionOK
+}
@@ -0,0 +1,86 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+// #include <tor_api.h>
+import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// Positions in a new circuit's path the path handler is consulted for.
+const (
+	PathEntry  = C.TOR_PATH_ENTRY
+	PathMiddle = C.TOR_PATH_MIDDLE
+	PathExit   = C.TOR_PATH_EXIT
+)
+
+// PathCandidate is a relay the embedded tor considers for a position in a new
+// circuit's path.
+type PathCandidate struct {
+	Fingerprint string
+	Nickname    string
+	Address     string
+	Country     string
+	Weight      float64
+	Veto        bool
+}
+
+var (
+	pathHandler func(position int, purpose string, candidates []PathCandidate) // Path handler of the running instance
+	pathLock    sync.RWMutex                                                   // Lock protecting the path handler
+)
+
+// setPathHandler replaces the path handler of the running tor instance.
+func setPathHandler(handler func(position int, purpose string, candidates []PathCandidate)) {
+	pathLock.Lock()
+	defer pathLock.Unlock()
+
+	pathHandler = handler
+}
+
+// setPathEnabled enables or disables consulting the path handler.
+func setPathEnabled(enabled bool) {
+	if enabled {
+		C.tor_set_path_callback_enabled(1)
+	} else {
+		C.tor_set_path_callback_enabled(0)
+	}
+}
+
+// pathCallback is invoked by tor from its main loop when choosing a relay for a
+// position in a new circuit's path, converting the candidates to their Go
+// counterparts and writing the weights and vetoes of the handler back.
+//
+//export pathCallback
+func pathCallback(position C.int, purpose *C.char, candidates unsafe.Pointer, n C.int) {
+	pathLock.RLock()
+	handler := pathHandler
+	pathLock.RUnlock()
+
+	if handler == nil || n <= 0 {
+		return
+	}
+	raw := (*[1 << 20]C.tor_path_candidate_t)(candidates)[:n:n]
+
+	nodes := make([]PathCandidate, n)
+	for i := range raw {
+		nodes[i] = PathCandidate{
+			Fingerprint: C.GoString(&raw[i].fingerprint[0]),
+			Nickname:    C.GoString(raw[i].nickname),
+			Address:     C.GoString(&raw[i].address[0]),
+			Country:     C.GoString(&raw[i].country[0]),
+			Weight:      float64(raw[i].weight),
+		}
+	}
+	handler(int(position), C.GoString(purpose), nodes)
+
+	for i := range raw {
+		raw[i].weight = C.double(nodes[i].Weight)
+		if nodes[i].Veto {
+			raw[i].veto = 1
+		}
+	}
+}
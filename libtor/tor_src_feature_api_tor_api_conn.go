@@ -0,0 +1,11 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+/*
+#define BUILDDIR ""
+
+#include <../src/feature/api/tor_api_conn.c>
+*/
+import "C"
@@ -0,0 +1,60 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipsn/go-libtor/internal/mallinfo"
+)
+
+// Tests that tor can be started and stopped repeatedly without leaking file
+// descriptors or C heap allocations across the cycles.
+func TestRestartLeaks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping restart soak in short mode")
+	}
+	cycle := func() {
+		tor, err := Start(context.Background(), &Config{Args: []string{"--DisableNetwork", "1"}})
+		if err != nil {
+			t.Fatalf("failed to start tor: %v", err)
+		}
+		if err := tor.Close(); err != nil {
+			t.Fatalf("failed to stop tor: %v", err)
+		}
+	}
+	// Run a few cycles first for the one-off global initializations to settle
+	for i := 0; i < 3; i++ {
+		cycle()
+	}
+	fds := openFiles(t)
+	heap, heapOK := mallinfo.InUse()
+
+	const cycles = 30
+	for i := 0; i < cycles; i++ {
+		cycle()
+	}
+	if have := openFiles(t); have != fds {
+		t.Errorf("open file descriptors changed over %d cycles: have %d, want %d", cycles, have, fds)
+	}
+	if !heapOK {
+		t.Logf("C heap statistics unavailable, skipping heap check")
+		return
+	}
+	// Allow for some allocator noise, but a leak per cycle would surpass it
+	if have, _ := mallinfo.InUse(); have > heap+256*1024 {
+		t.Errorf("C heap grew over %d cycles: have %d bytes, had %d bytes", cycles, have, heap)
+	}
+}
+
+// openFiles returns the number of file descriptors open in the process.
+func openFiles(t *testing.T) int {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skipf("failed to list open files: %v", err)
+	}
+	return len(fds)
+}
@@ -0,0 +1,149 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LogHandler is a callback receiving the messages logged by the embedded tor.
+//
+// The handler is invoked synchronously from tor's threads, so it should return
+// quickly and must not block on tor (e.g. issue control commands). Messages are
+// scrubbed the same way as for any other tor log, according to SafeLogging.
+type LogHandler func(severity LogSeverity, domain LogDomain, msg string)
+
+// LogSeverity is the severity of a tor log message.
+type LogSeverity int
+
+// Severities of tor's log messages, matching the syslog levels tor uses.
+const (
+	LogErr    LogSeverity = 3 // Unrecoverable errors, tor is about to exit
+	LogWarn   LogSeverity = 4 // Problems that need attention
+	LogNotice LogSeverity = 5 // Important status messages, tor's default level
+	LogInfo   LogSeverity = 6 // Verbose operational messages
+	LogDebug  LogSeverity = 7 // Very verbose messages for debugging tor
+)
+
+// logSeverityNames are the names of the log severities as tor expects them.
+var logSeverityNames = map[LogSeverity]string{
+	LogErr:    "err",
+	LogWarn:   "warn",
+	LogNotice: "notice",
+	LogInfo:   "info",
+	LogDebug:  "debug",
+}
+
+// String implements fmt.Stringer, returning tor's name for the severity.
+func (s LogSeverity) String() string {
+	if name, ok := logSeverityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// LogDomain is a set of tor subsystems a log message originates from.
+type LogDomain uint32
+
+// Log domains of tor, which can be combined to filter multiple ones at once.
+const (
+	LogGeneral LogDomain = 1 << iota
+	LogCrypto
+	LogNet
+	LogConfig
+	LogFS
+	LogProtocol
+	LogMM
+	LogHTTP
+	LogApp
+	LogControl
+	LogCirc
+	LogRend
+	LogBug
+	LogDir
+	LogDirServ
+	LogOR
+	LogEdge
+	LogAcct
+	LogHist
+	LogHandshake
+	LogHeartbeat
+	LogChannel
+	LogSched
+	LogGuard
+	LogConsDiff
+	LogDoS
+)
+
+// logDomainNames are the names of the log domains as tor expects them, indexed
+// by their bit positions.
+var logDomainNames = []string{
+	"general", "crypto", "net", "config", "fs", "protocol", "mm", "http", "app",
+	"control", "circ", "rend", "bug", "dir", "dirserv", "or", "edge", "acct",
+	"hist", "handshake", "heartbeat", "channel", "sched", "guard", "consdiff", "dos",
+}
+
+// String implements fmt.Stringer, returning the comma separated names of the
+// domains in the set.
+func (d LogDomain) String() string {
+	var names []string
+	for i, name := range logDomainNames {
+		if d&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// allLogDomains is the set of all log domains known to tor. Tor uses the higher
+// bits internally as message flags.
+const allLogDomains = LogDomain(1<<26 - 1)
+
+// logSeverityConfig assembles tor's severity configuration for the given default
+// minimum severity and its per domain overrides.
+func logSeverityConfig(level LogSeverity, overrides map[LogDomain]LogSeverity) (string, error) {
+	if level == 0 {
+		level = LogNotice
+	}
+	if _, ok := logSeverityNames[level]; !ok {
+		return "", fmt.Errorf("invalid log severity: %d", int(level))
+	}
+	// Sort the overrides to keep the configuration stable
+	domains := make([]LogDomain, 0, len(overrides))
+	for domain := range overrides {
+		domains = append(domains, domain)
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i] < domains[j] })
+
+	var (
+		overridden LogDomain
+		entries    []string
+	)
+	for _, domain := range domains {
+		if domain&allLogDomains == 0 || domain&^allLogDomains != 0 {
+			return "", fmt.Errorf("invalid log domain: %#x", uint32(domain))
+		}
+		if overridden&domain != 0 {
+			return "", fmt.Errorf("log domain overridden multiple times: %v", overridden&domain)
+		}
+		overridden |= domain
+
+		severity := overrides[domain]
+		if _, ok := logSeverityNames[severity]; !ok {
+			return "", fmt.Errorf("invalid log severity for %v: %d", domain, int(severity))
+		}
+		entries = append(entries, fmt.Sprintf("[%v]%v", domain, severity))
+	}
+	// Apply the default severity to all the domains not overridden
+	if overridden == 0 {
+		return level.String(), nil
+	}
+	if overridden != allLogDomains {
+		excluded := strings.Split(overridden.String(), ",")
+		entries = append([]string{fmt.Sprintf("[~%s]%v", strings.Join(excluded, ",~"), level)}, entries...)
+	}
+	return strings.Join(entries, " "), nil
+}
@@ -0,0 +1,75 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"strings"
+	"testing"
+)
+
+// Tests that log severity configurations are assembled in tor's syntax, with the
+// default severity applied to all the domains not overridden.
+func TestLogSeverityConfig(t *testing.T) {
+	all := strings.Join(logDomainNames, ",")
+	tests := []struct {
+		level     LogSeverity
+		overrides map[LogDomain]LogSeverity
+		config    string
+		fail      bool
+	}{
+		// Default severity only
+		{config: "notice"},
+		{level: LogWarn, config: "warn"},
+		{level: LogDebug, overrides: map[LogDomain]LogSeverity{}, config: "debug"},
+
+		// Single override, of one or multiple domains
+		{
+			overrides: map[LogDomain]LogSeverity{LogNet: LogDebug},
+			config:    "[~net]notice [net]debug",
+		},
+		{
+			level:     LogWarn,
+			overrides: map[LogDomain]LogSeverity{LogNet | LogCirc: LogInfo},
+			config:    "[~net,~circ]warn [net,circ]info",
+		},
+		// Multiple overrides, ordered by domain
+		{
+			overrides: map[LogDomain]LogSeverity{LogCirc: LogInfo, LogNet: LogDebug},
+			config:    "[~net,~circ]notice [net]debug [circ]info",
+		},
+		// All domains overridden, leaving nothing for the default severity
+		{
+			level:     LogErr,
+			overrides: map[LogDomain]LogSeverity{allLogDomains: LogInfo},
+			config:    "[" + all + "]info",
+		},
+		{
+			overrides: map[LogDomain]LogSeverity{LogGeneral: LogWarn, allLogDomains &^ LogGeneral: LogDebug},
+			config:    "[general]warn [" + strings.TrimPrefix(all, "general,") + "]debug",
+		},
+		// Overlapping overrides
+		{overrides: map[LogDomain]LogSeverity{LogNet: LogDebug, LogNet | LogCirc: LogInfo}, fail: true},
+		{overrides: map[LogDomain]LogSeverity{allLogDomains: LogInfo, LogDoS: LogDebug}, fail: true},
+
+		// Invalid severities and domains
+		{level: 2, fail: true},
+		{level: 8, fail: true},
+		{overrides: map[LogDomain]LogSeverity{LogNet: 0}, fail: true},
+		{overrides: map[LogDomain]LogSeverity{LogNet: 9}, fail: true},
+		{overrides: map[LogDomain]LogSeverity{0: LogInfo}, fail: true},
+		{overrides: map[LogDomain]LogSeverity{1 << 26: LogInfo}, fail: true},
+		{overrides: map[LogDomain]LogSeverity{LogNet | 1<<30: LogInfo}, fail: true},
+	}
+	for i, tt := range tests {
+		config, err := logSeverityConfig(tt.level, tt.overrides)
+		switch {
+		case tt.fail && err == nil:
+			t.Errorf("test %d: invalid configuration accepted: %q", i, config)
+		case !tt.fail && err != nil:
+			t.Errorf("test %d: valid configuration rejected: %v", i, err)
+		case !tt.fail && config != tt.config:
+			t.Errorf("test %d: configuration mismatch: have %q, want %q", i, config, tt.config)
+		}
+	}
+}
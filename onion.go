@@ -0,0 +1,279 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/cretz/bine/torutil"
+	"github.com/cretz/bine/torutil/ed25519"
+	"github.com/ipsn/go-libtor/control"
+)
+
+// errListenerClosed is returned when using an onion listener that was closed.
+var errListenerClosed = errors.New("use of closed onion listener")
+
+// GenerateOnionKey generates a new identity key for a v3 onion service.
+func GenerateOnionKey() (ed25519.KeyPair, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// OnionConfig contains the parameters of a v3 onion service to publish.
+type OnionConfig struct {
+	Key   ed25519.KeyPair // Identity key of the service, a new one is generated if nil
+	Ports []int           // Virtual ports the service is reachable at, at least one
+
+	MaxStreams             int  // Maximum number of concurrent streams per rendezvous circuit, 0 for unlimited
+	MaxStreamsCloseCircuit bool // Close the circuit instead of rejecting streams over MaxStreams
+}
+
+// OnionAddr is the address of an onion service, at one of its virtual ports.
+type OnionAddr struct {
+	ID   string // Onion address of the service, without the .onion suffix
+	Port int    // Virtual port of the service
+}
+
+// Network implements net.Addr.
+func (a *OnionAddr) Network() string { return "onion" }
+
+// String implements net.Addr, returning the address as "id.onion:port".
+func (a *OnionAddr) String() string {
+	return net.JoinHostPort(a.ID+".onion", strconv.Itoa(a.Port))
+}
+
+// OnionListener is a v3 onion service published by the embedded tor, accepting
+// the connections made to any of its virtual ports. The local address of the
+// accepted connections is the *OnionAddr of the virtual port they came in on.
+//
+// Tor forwards the virtual ports to unix sockets in a private directory only
+// accessible to the user running the process, never to local TCP ports other
+// processes could connect to, bypassing the onion service.
+//
+// The service is removed when the listener is closed, or when tor terminates.
+type OnionListener struct {
+	tor  *Tor
+	key  ed25519.KeyPair
+	id   string
+	addr *OnionAddr
+
+	sockdir string         // Private directory holding the sockets of the virtual ports
+	locals  []net.Listener // Local listeners tor forwards the virtual ports to
+	conns   chan net.Conn  // Connections accepted on any of the local listeners
+
+	events    *EventSubscription // Subscription to the upload events of the descriptor
+	published chan struct{}      // Channel closed when the descriptor is first uploaded
+	announce  sync.Once
+
+	closed chan struct{} // Channel closed when the listener is closed
+	close  sync.Once
+}
+
+// ListenOnion publishes a v3 onion service, forwarding its virtual ports to the
+// returned listener. The service is reachable once its descriptor is uploaded,
+// which WaitPublished waits for.
+func (t *Tor) ListenOnion(ctx context.Context, config *OnionConfig) (*OnionListener, error) {
+	if config == nil {
+		return nil, errors.New("missing onion service configuration")
+	}
+	if len(config.Ports) == 0 {
+		return nil, errors.New("onion service without ports")
+	}
+	key := config.Key
+	if key == nil {
+		var err error
+		if key, err = GenerateOnionKey(); err != nil {
+			return nil, fmt.Errorf("failed to generate onion key: %v", err)
+		}
+	}
+	l := &OnionListener{
+		tor:       t,
+		key:       key,
+		id:        torutil.OnionServiceIDFromV3PublicKey(key.PublicKey()),
+		conns:     make(chan net.Conn),
+		published: make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	l.addr = &OnionAddr{ID: l.id, Port: config.Ports[0]}
+
+	// Open the local listeners of the virtual ports in a private directory
+	sockdir, err := ioutil.TempDir("", "libtor-onion")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %v", err)
+	}
+	if err := os.Chmod(sockdir, 0700); err != nil {
+		os.RemoveAll(sockdir)
+		return nil, fmt.Errorf("failed to restrict socket directory: %v", err)
+	}
+	l.sockdir = sockdir
+
+	req := &control.AddOnionRequest{
+		KeyType:                "ED25519-V3",
+		Key:                    base64.StdEncoding.EncodeToString(key.PrivateKey()),
+		MaxStreams:             config.MaxStreams,
+		DiscardKey:             true,
+		MaxStreamsCloseCircuit: config.MaxStreamsCloseCircuit,
+	}
+	seen := make(map[int]bool)
+	for _, port := range config.Ports {
+		if port <= 0 || port > 65535 || seen[port] {
+			l.closeLocals()
+			return nil, fmt.Errorf("invalid onion port: %d", port)
+		}
+		seen[port] = true
+
+		path := filepath.Join(sockdir, strconv.Itoa(port)+".sock")
+		local, err := net.Listen("unix", path)
+		if err != nil {
+			l.closeLocals()
+			return nil, err
+		}
+		l.locals = append(l.locals, local)
+		req.Ports = append(req.Ports, control.OnionPort{Virtual: port, Target: control.UnixTarget(path)})
+	}
+	// Watch for the descriptor uploads before publishing to not miss any
+	events, err := t.events.subscribeFunc(l.handle, EventHSDesc)
+	if err != nil {
+		l.closeLocals()
+		return nil, err
+	}
+	l.events = events
+
+	res, err := t.ctrl.AddOnion(ctx, req)
+	if err != nil {
+		l.events.Close()
+		l.closeLocals()
+		return nil, err
+	}
+	if res.ServiceID != l.id {
+		l.Close()
+		return nil, fmt.Errorf("onion service published as %s, expected %s", res.ServiceID, l.id)
+	}
+	for i, local := range l.locals {
+		go l.accept(local, &OnionAddr{ID: l.id, Port: config.Ports[i]})
+	}
+	return l, nil
+}
+
+// ID returns the onion address of the service, without the .onion suffix.
+func (l *OnionListener) ID() string {
+	return l.id
+}
+
+// Key returns the identity key of the service.
+func (l *OnionListener) Key() ed25519.KeyPair {
+	return l.key
+}
+
+// Addr implements net.Listener, returning the *OnionAddr of the first virtual
+// port of the service.
+func (l *OnionListener) Addr() net.Addr {
+	return l.addr
+}
+
+// Accept implements net.Listener, waiting for the next connection to any of the
+// virtual ports of the service.
+func (l *OnionListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, &net.OpError{Op: "accept", Net: "onion", Addr: l.addr, Err: errListenerClosed}
+	}
+}
+
+// Close implements net.Listener, removing the service from tor and closing the
+// local listeners. Connections already accepted are left open.
+func (l *OnionListener) Close() error {
+	err := errListenerClosed
+	l.close.Do(func() {
+		close(l.closed)
+		l.events.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+		defer cancel()
+
+		err = l.tor.ctrl.DelOnion(ctx, l.id)
+		select {
+		case <-l.tor.ctrl.Closed():
+			err = nil // Tor terminated, taking the service with it
+		default:
+		}
+		l.closeLocals()
+	})
+	return err
+}
+
+// WaitPublished waits until the descriptor of the service is first uploaded to
+// a directory, after which clients can connect to it.
+func (l *OnionListener) WaitPublished(ctx context.Context) error {
+	select {
+	case <-l.published:
+		return nil
+	case <-l.closed:
+		return errListenerClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handle marks the service published on the first upload of its descriptor. It
+// runs on tor's main loop.
+func (l *OnionListener) handle(event Event) {
+	if ev := event.(*HSDescEvent); ev.Action == "UPLOADED" && ev.Address == l.id {
+		l.announce.Do(func() { close(l.published) })
+	}
+}
+
+// accept hands the connections of a local listener over to Accept, until the
+// listener is closed.
+func (l *OnionListener) accept(local net.Listener, addr *OnionAddr) {
+	for {
+		conn, err := local.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+		select {
+		case l.conns <- &onionConn{Conn: conn, local: addr}:
+		case <-l.closed:
+			conn.Close()
+			return
+		}
+	}
+}
+
+// closeLocals closes the local listeners of the virtual ports, removing their
+// socket directory.
+func (l *OnionListener) closeLocals() {
+	for _, local := range l.locals {
+		local.Close()
+	}
+	if l.sockdir != "" {
+		os.RemoveAll(l.sockdir)
+	}
+}
+
+// onionConn is a connection accepted by an onion listener, reporting the virtual
+// port it came in on as its local address.
+type onionConn struct {
+	net.Conn
+	local *OnionAddr
+}
+
+// LocalAddr implements net.Conn.
+func (c *onionConn) LocalAddr() net.Addr {
+	return c.local
+}
@@ -0,0 +1,133 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Tests that publishing an onion service opens no TCP listeners, neither in Go
+// nor within tor, that other local processes could connect to.
+func TestListenOnionNoTCPListeners(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping networked tor in short mode")
+	}
+	tor, err := Start(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to start tor: %v", err)
+	}
+	defer tor.Close()
+
+	before := tcpListeners(t)
+
+	listener, err := tor.ListenOnion(context.Background(), &OnionConfig{Ports: []int{80, 443}})
+	if err != nil {
+		t.Fatalf("failed to publish onion service: %v", err)
+	}
+	defer listener.Close()
+
+	for addr := range tcpListeners(t) {
+		if !before[addr] {
+			t.Errorf("new TCP listener opened: %s", addr)
+		}
+	}
+}
+
+// tcpListeners returns the local addresses (in /proc/net hex notation) of the
+// listening TCP sockets owned by the process, IPv4 and IPv6 alike.
+func tcpListeners(t *testing.T) map[string]bool {
+	// Collect the inodes of the process' sockets to skip other processes' ones
+	fds, err := filepath.Glob("/proc/self/fd/*")
+	if err != nil || len(fds) == 0 {
+		t.Skipf("failed to list open files: %v", err)
+	}
+	inodes := make(map[string]bool)
+	for _, fd := range fds {
+		if target, err := os.Readlink(fd); err == nil && strings.HasPrefix(target, "socket:[") {
+			inodes[strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")] = true
+		}
+	}
+	listeners := make(map[string]bool)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(table)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // No IPv6 support
+			}
+			t.Fatalf("failed to open %s: %v", table, err)
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Scan() // Skip the header line
+		for scanner.Scan() {
+			// Fields: sl local_address rem_address st tx:rx tr:when retrnsmt uid timeout inode
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != "0A" || !inodes[fields[9]] {
+				continue
+			}
+			listeners[fields[1]] = true
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", table, err)
+		}
+	}
+	return listeners
+}
+
+// Tests that onion services without a configuration or ports are rejected with
+// an error instead of crashing.
+func TestListenOnionInvalidConfig(t *testing.T) {
+	for i, config := range []*OnionConfig{nil, {}, {Ports: []int{}}} {
+		if _, err := new(Tor).ListenOnion(context.Background(), config); err == nil {
+			t.Errorf("test %d: invalid configuration accepted", i)
+		}
+	}
+}
+
+// Tests that onion services are published even if the sockets of their virtual
+// ports are in a directory tor would split the arguments on unless quoted.
+func TestListenOnionQuotedSockets(t *testing.T) {
+	root, err := ioutil.TempDir("", "libtor-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	tmpdir := filepath.Join(root, `with space, "quotes" and \`)
+	if err := os.Mkdir(tmpdir, 0700); err != nil {
+		t.Fatalf("failed to create socket root: %v", err)
+	}
+	defer os.Setenv("TMPDIR", os.Getenv("TMPDIR"))
+	os.Setenv("TMPDIR", tmpdir)
+
+	tor, err := Start(context.Background(), &Config{Args: []string{"--DisableNetwork", "1"}})
+	if err != nil {
+		t.Fatalf("failed to start tor: %v", err)
+	}
+	defer tor.Close()
+
+	listener, err := tor.ListenOnion(context.Background(), &OnionConfig{Ports: []int{80, 443}})
+	if err != nil {
+		t.Fatalf("failed to publish onion service: %v", err)
+	}
+	defer listener.Close()
+
+	if !strings.HasPrefix(listener.sockdir, tmpdir) {
+		t.Errorf("socket directory mismatch: have %s, want within %s", listener.sockdir, tmpdir)
+	}
+	onions, err := tor.Control().GetInfo(context.Background(), "onions/current")
+	if err != nil {
+		t.Fatalf("failed to list onion services: %v", err)
+	}
+	if have := strings.TrimSpace(onions["onions/current"]); have != listener.ID() {
+		t.Errorf("onion service mismatch: have %q, want %q", have, listener.ID())
+	}
+}
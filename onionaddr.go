@@ -0,0 +1,96 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cretz/bine/torutil/ed25519"
+	"github.com/ipsn/go-libtor/libtor"
+)
+
+// Errors returned when validating v3 onion addresses and keys.
+var (
+	ErrOnionMalformed = errors.New("malformed onion address")
+	ErrOnionChecksum  = errors.New("invalid onion address checksum")
+	ErrOnionVersion   = errors.New("unsupported onion address version")
+	ErrOnionKey       = errors.New("invalid onion service key")
+)
+
+// OnionTimePeriodLength is the length of the time periods v3 onion service keys
+// are blinded for on the live network. Tor takes it from the consensus, which
+// has never deviated from the default.
+const OnionTimePeriodLength = 24 * time.Hour
+
+// onionTimePeriodOffset is the offset of the time periods from the Unix epoch,
+// starting them in the middle of the shared random protocol run.
+const onionTimePeriodOffset = 12 * time.Hour
+
+// ParseOnionAddress validates a v3 onion address (with or without the .onion
+// suffix, in any case) the same way tor does, checking its length, encoding,
+// checksum and version byte, and returns the identity key of the service.
+func ParseOnionAddress(address string) (ed25519.PublicKey, error) {
+	id := strings.TrimSuffix(strings.ToLower(address), ".onion")
+
+	pubkey, res := libtor.OnionParseAddress(id)
+	if err := onionError(res); err != nil {
+		return nil, err
+	}
+	return ed25519.PublicKey(pubkey), nil
+}
+
+// OnionAddress derives the v3 onion address (without the .onion suffix) of the
+// service with the given identity key.
+func OnionAddress(key ed25519.PublicKey) (string, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return "", ErrOnionKey
+	}
+	address, res := libtor.OnionBuildAddress(key)
+	if err := onionError(res); err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+// OnionTimePeriod returns the number of the time period a v3 onion service's
+// descriptor is published for at the given time.
+func OnionTimePeriod(t time.Time) uint64 {
+	minutes := t.Unix()/60 - int64(onionTimePeriodOffset/time.Minute)
+	if minutes < 0 {
+		return 0
+	}
+	return uint64(minutes) / uint64(OnionTimePeriodLength/time.Minute)
+}
+
+// BlindOnionKey derives the blinded key of a v3 onion service for the given
+// time period, which its descriptor is stored and looked up by at the hidden
+// service directories.
+func BlindOnionKey(key ed25519.PublicKey, period uint64) (ed25519.PublicKey, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return nil, ErrOnionKey
+	}
+	blinded, res := libtor.OnionBlindKey(key, period, uint64(OnionTimePeriodLength/time.Minute))
+	if err := onionError(res); err != nil {
+		return nil, err
+	}
+	return ed25519.PublicKey(blinded), nil
+}
+
+// onionError converts the result of tor's onion address helpers to an error.
+func onionError(res int) error {
+	switch res {
+	case libtor.OnionOK:
+		return nil
+	case libtor.OnionBadChecksum:
+		return ErrOnionChecksum
+	case libtor.OnionBadVersion:
+		return ErrOnionVersion
+	case libtor.OnionBadKey:
+		return ErrOnionKey
+	default:
+		return ErrOnionMalformed
+	}
+}
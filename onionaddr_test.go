@@ -0,0 +1,259 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cretz/bine/torutil"
+	"github.com/cretz/bine/torutil/ed25519"
+	"golang.org/x/crypto/sha3"
+)
+
+// testOnionKey is the RFC 8032 section 7.1 test 1 public key, which tor's
+// hs_build_address unit test derives testOnionAddress from.
+var testOnionKey, _ = hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+
+// Tests that onion addresses are derived from and parsed back into the identity
+// keys of the services, matching tor's test vector and bine's derivations.
+func TestOnionAddressRoundTrip(t *testing.T) {
+	address, err := OnionAddress(testOnionKey)
+	if err != nil {
+		t.Fatalf("failed to derive onion address: %v", err)
+	}
+	if address != testOnionAddress {
+		t.Errorf("address mismatch: have %s, want %s", address, testOnionAddress)
+	}
+	for _, onion := range []string{testOnionAddress, testOnionAddress + ".onion", strings.ToUpper(testOnionAddress) + ".ONION"} {
+		key, err := ParseOnionAddress(onion)
+		if err != nil {
+			t.Errorf("%s: failed to parse address: %v", onion, err)
+			continue
+		}
+		if !bytes.Equal(key, testOnionKey) {
+			t.Errorf("%s: key mismatch: have %x, want %x", onion, key, testOnionKey)
+		}
+	}
+	for i := 0; i < 16; i++ {
+		pair, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		address, err := OnionAddress(pair.PublicKey())
+		if err != nil {
+			t.Fatalf("failed to derive onion address: %v", err)
+		}
+		if want := torutil.OnionServiceIDFromV3PublicKey(pair.PublicKey()); address != want {
+			t.Errorf("address mismatch: have %s, want %s", address, want)
+		}
+		key, err := ParseOnionAddress(address)
+		if err != nil {
+			t.Fatalf("%s: failed to parse address: %v", address, err)
+		}
+		if !bytes.Equal(key, pair.PublicKey()) {
+			t.Errorf("%s: key mismatch: have %x, want %x", address, key, pair.PublicKey())
+		}
+	}
+}
+
+// testOnionAddressOf assembles an onion address from its parts, with a valid
+// checksum unless overridden.
+func testOnionAddressOf(key []byte, checksum []byte, version byte) string {
+	if checksum == nil {
+		hash := sha3.Sum256(append(append([]byte(".onion checksum"), key...), version))
+		checksum = hash[:2]
+	}
+	blob := append(append(append([]byte{}, key...), checksum...), version)
+	return strings.ToLower(base32.StdEncoding.EncodeToString(blob))
+}
+
+// Tests that malformed onion addresses are rejected with the error of the check
+// they fail.
+func TestOnionAddressInvalid(t *testing.T) {
+	// Sanity check the address assembly against the known vector
+	if have := testOnionAddressOf(testOnionKey, nil, 3); have != testOnionAddress {
+		t.Fatalf("assembled address mismatch: have %s, want %s", have, testOnionAddress)
+	}
+	// The checksum of the known vector, and an encoding of a y coordinate with no
+	// point on the curve
+	blob, _ := base32.StdEncoding.DecodeString(strings.ToUpper(testOnionAddress))
+	checksum := blob[32:34]
+	offcurve, _ := hex.DecodeString("0200000000000000000000000000000000000000000000000000000000000000")
+
+	tests := []struct {
+		address string
+		err     error
+	}{
+		{"", ErrOnionMalformed},
+		{testOnionAddress[:55], ErrOnionMalformed},
+		{testOnionAddress + "a", ErrOnionMalformed},
+		{testOnionAddress[:52] + "aaaa" + testOnionAddress[52:], ErrOnionMalformed},
+		{"expyuzz4wqqyqhjn", ErrOnionMalformed},
+		{testOnionAddress[:55] + "1", ErrOnionMalformed},
+		{testOnionAddress[:20] + "0" + testOnionAddress[21:], ErrOnionMalformed},
+		{testOnionAddressOf(testOnionKey, []byte{0x00, 0x00}, 3), ErrOnionChecksum},
+		{testOnionAddressOf(append([]byte{testOnionKey[0] ^ 1}, testOnionKey[1:]...), checksum, 3), ErrOnionChecksum},
+		{testOnionAddressOf(testOnionKey, nil, 2), ErrOnionVersion},
+		{testOnionAddressOf(testOnionKey, nil, 4), ErrOnionVersion},
+		{testOnionAddressOf(testOnionKey, nil, 0), ErrOnionVersion},
+		{testOnionAddressOf(offcurve, nil, 3), ErrOnionKey},
+	}
+	for i, tt := range tests {
+		if _, err := ParseOnionAddress(tt.address); err != tt.err {
+			t.Errorf("test %d (%s): error mismatch: have %v, want %v", i, tt.address, err, tt.err)
+		}
+	}
+	for _, key := range [][]byte{nil, testOnionKey[:31], append(testOnionKey, 0)} {
+		if _, err := OnionAddress(key); err != ErrOnionKey {
+			t.Errorf("%x: error mismatch: have %v, want %v", key, err, ErrOnionKey)
+		}
+	}
+}
+
+// Tests that the time periods match the ones of tor's hs_get_time_period_num,
+// rotating at 12:00 UTC each day.
+func TestOnionTimePeriod(t *testing.T) {
+	tests := []struct {
+		time   string
+		period uint64
+	}{
+		// Vectors of tor's test_time_period unit test
+		{"2016-04-13T11:00:00Z", 16903},
+		{"2016-04-13T11:59:59Z", 16903},
+		{"2016-04-13T12:00:00Z", 16904},
+
+		{"1970-01-01T12:00:00Z", 0},
+		{"1970-01-02T11:59:59Z", 0},
+		{"1970-01-02T12:00:00Z", 1},
+		{"2018-11-05T00:00:00Z", 17839},
+		{"2018-11-05T12:00:00Z", 17840},
+		{"2018-11-05T23:59:59Z", 17840},
+		{"2038-01-19T03:14:08Z", 24854},
+	}
+	for _, tt := range tests {
+		at, err := time.Parse(time.RFC3339, tt.time)
+		if err != nil {
+			t.Fatalf("failed to parse time %s: %v", tt.time, err)
+		}
+		if have := OnionTimePeriod(at); have != tt.period {
+			t.Errorf("%s: period mismatch: have %d, want %d", tt.time, have, tt.period)
+		}
+		// Time zones must not shift the rotation
+		if have := OnionTimePeriod(at.In(time.FixedZone("UTC+5", 5*3600))); have != tt.period {
+			t.Errorf("%s: zoned period mismatch: have %d, want %d", tt.time, have, tt.period)
+		}
+	}
+}
+
+// Tests that the blinded keys match the one tor's hs_build_blinded_pubkey derives
+// for the known key in the period of tor's time period vectors, and the ones of
+// a reference implementation of rend-spec-v3 appendix A.2 for other periods.
+func TestBlindOnionKey(t *testing.T) {
+	blinded, err := BlindOnionKey(testOnionKey, 16904)
+	if err != nil {
+		t.Fatalf("failed to blind key: %v", err)
+	}
+	if want, _ := hex.DecodeString("8b8ea1a4fcf12f8de62be01b5055afe3650b6fbcaaa108448dc543a690fb68da"); !bytes.Equal(blinded, want) {
+		t.Errorf("blinded key mismatch: have %x, want %x", blinded, want)
+	}
+	for _, period := range []uint64{0, 1, 16903, 16904, 17840, 1<<32 + 1} {
+		blinded, err := BlindOnionKey(testOnionKey, period)
+		if err != nil {
+			t.Fatalf("period %d: failed to blind key: %v", period, err)
+		}
+		if want := testBlindOnionKey(testOnionKey, period, 1440); !bytes.Equal(blinded, want) {
+			t.Errorf("period %d: blinded key mismatch: have %x, want %x", period, blinded, want)
+		}
+	}
+}
+
+// Curve parameters of ed25519 for the reference key blinding.
+var (
+	testCurveP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	testCurveD = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(-121665), new(big.Int).ModInverse(big.NewInt(121666), testCurveP)), testCurveP)
+)
+
+// testBlindOnionKey is a slow reference implementation of the key blinding of
+// rend-spec-v3 appendix A.2, independent of tor's.
+func testBlindOnionKey(key []byte, period uint64, length uint64) []byte {
+	// Derive the blinding factor of the time period
+	nonce := make([]byte, 25)
+	copy(nonce, "key-blind")
+	binary.BigEndian.PutUint64(nonce[9:], period)
+	binary.BigEndian.PutUint64(nonce[17:], length)
+
+	hash := sha3.New256()
+	hash.Write([]byte("Derive temporary signing key\x00"))
+	hash.Write(key)
+	hash.Write([]byte("(15112221349535400772501151409588531511454012693041857206046113283949847762202, 46316835694926478169428394003475163141307993866256225615783033603165251855960)"))
+	hash.Write(nonce)
+	factor := hash.Sum(nil)
+	factor[0] &= 248
+	factor[31] &= 63
+	factor[31] |= 64
+
+	// Decompress the key into a point and multiply it by the factor
+	p := testCurveP
+	y := new(big.Int).SetBytes(testReverse(key))
+	sign := y.Bit(255)
+	y.SetBit(y, 255, 0)
+
+	yy := new(big.Int).Mul(y, y)
+	num := new(big.Int).Sub(yy, big.NewInt(1))
+	den := new(big.Int).Add(new(big.Int).Mul(testCurveD, yy), big.NewInt(1))
+	xx := new(big.Int).Mod(new(big.Int).Mul(num, new(big.Int).ModInverse(den, p)), p)
+	x := new(big.Int).ModSqrt(xx, p)
+	if x.Bit(0) != sign {
+		x.Sub(p, x)
+	}
+	rx, ry := big.NewInt(0), big.NewInt(1)
+	scalar := new(big.Int).SetBytes(testReverse(factor))
+	for i := scalar.BitLen() - 1; i >= 0; i-- {
+		rx, ry = testCurveAdd(rx, ry, rx, ry)
+		if scalar.Bit(i) == 1 {
+			rx, ry = testCurveAdd(rx, ry, x, y)
+		}
+	}
+	// Compress the blinded point back into a key
+	ry.SetBit(ry, 255, rx.Bit(0))
+	out := make([]byte, 32)
+	blob := ry.Bytes()
+	copy(out[32-len(blob):], blob)
+	return testReverse(out)
+}
+
+// testCurveAdd adds two points of the twisted Edwards curve of ed25519.
+func testCurveAdd(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p := testCurveP
+	dxy := new(big.Int).Mul(testCurveD, new(big.Int).Mul(new(big.Int).Mul(x1, x2), new(big.Int).Mul(y1, y2)))
+	dxy.Mod(dxy, p)
+
+	xnum := new(big.Int).Add(new(big.Int).Mul(x1, y2), new(big.Int).Mul(y1, x2))
+	xden := new(big.Int).Add(big.NewInt(1), dxy)
+	ynum := new(big.Int).Add(new(big.Int).Mul(y1, y2), new(big.Int).Mul(x1, x2))
+	yden := new(big.Int).Sub(big.NewInt(1), dxy)
+	yden.Mod(yden, p)
+
+	x3 := new(big.Int).Mod(new(big.Int).Mul(xnum, new(big.Int).ModInverse(xden, p)), p)
+	y3 := new(big.Int).Mod(new(big.Int).Mul(ynum, new(big.Int).ModInverse(yden, p)), p)
+	return x3, y3
+}
+
+// testReverse returns a reversed copy of a byte slice, converting between the
+// little endian encodings of ed25519 and big.Int's big endian ones.
+func testReverse(blob []byte) []byte {
+	out := make([]byte, len(blob))
+	for i, b := range blob {
+		out[len(blob)-1-i] = b
+	}
+	return out
+}
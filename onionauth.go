@@ -0,0 +1,221 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ipsn/go-libtor/control"
+	"golang.org/x/crypto/curve25519"
+)
+
+// onionAuthEncoding is the encoding of the client authorization keys in tor's
+// key files.
+var onionAuthEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// OnionAuthKey is the private x25519 key of a client authorized to access a v3
+// onion service.
+type OnionAuthKey [32]byte
+
+// OnionAuthPublicKey is the public x25519 key of a client authorized to access
+// a v3 onion service, the one the service is configured with.
+type OnionAuthPublicKey [32]byte
+
+// GenerateOnionAuthKey generates a new client authorization key.
+func GenerateOnionAuthKey() (OnionAuthKey, error) {
+	var key OnionAuthKey
+	if _, err := rand.Read(key[:]); err != nil {
+		return key, err
+	}
+	// Clamp the key the same way tor does
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+
+	return key, nil
+}
+
+// ParseOnionAuthKey parses a base32 encoded private client authorization key, as
+// found in tor's .auth_private files.
+func ParseOnionAuthKey(s string) (OnionAuthKey, error) {
+	var key OnionAuthKey
+	err := decodeOnionAuthKey(s, key[:])
+	return key, err
+}
+
+// ParseOnionAuthPublicKey parses a base32 encoded public client authorization
+// key, as found in tor's .auth files.
+func ParseOnionAuthPublicKey(s string) (OnionAuthPublicKey, error) {
+	var key OnionAuthPublicKey
+	err := decodeOnionAuthKey(s, key[:])
+	return key, err
+}
+
+// Public returns the public key of a client authorization key.
+func (k OnionAuthKey) Public() OnionAuthPublicKey {
+	var pub [32]byte
+	priv := [32]byte(k)
+	curve25519.ScalarBaseMult(&pub, &priv)
+	return OnionAuthPublicKey(pub)
+}
+
+// String returns the base32 encoding of the key, as used by tor.
+func (k OnionAuthKey) String() string {
+	return onionAuthEncoding.EncodeToString(k[:])
+}
+
+// String returns the base32 encoding of the key, as used by tor.
+func (k OnionAuthPublicKey) String() string {
+	return onionAuthEncoding.EncodeToString(k[:])
+}
+
+// decodeOnionAuthKey decodes a base32 encoded x25519 key into the given buffer.
+func decodeOnionAuthKey(s string, key []byte) error {
+	blob, err := onionAuthEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(s)))
+	if err != nil || len(blob) != len(key) {
+		return fmt.Errorf("invalid client authorization key: %q", s)
+	}
+	copy(key, blob)
+	return nil
+}
+
+// AddOnionAuth registers the credentials to access a v3 onion service (with or
+// without the .onion suffix) restricted to authorized clients, replacing any
+// previous ones of the service.
+//
+// The credentials are stored in the ClientOnionAuthDir, which is set up within
+// the data directory if not configured, and tor reloads them right away.
+func (t *Tor) AddOnionAuth(ctx context.Context, onion string, key OnionAuthKey) error {
+	id, err := onionServiceID(onion)
+	if err != nil {
+		return err
+	}
+	dir, err := t.onionAuthDir(ctx)
+	if err != nil {
+		return err
+	}
+	entry := fmt.Sprintf("%s:descriptor:x25519:%s\n", id, key)
+	if err := ioutil.WriteFile(filepath.Join(dir, id+".auth_private"), []byte(entry), 0600); err != nil {
+		return fmt.Errorf("failed to store client authorization: %v", err)
+	}
+	return t.reloadOnionAuth(ctx)
+}
+
+// RemoveOnionAuth drops the credentials to access a v3 onion service, registered
+// with AddOnionAuth.
+func (t *Tor) RemoveOnionAuth(ctx context.Context, onion string) error {
+	id, err := onionServiceID(onion)
+	if err != nil {
+		return err
+	}
+	dir, err := t.onionAuthDir(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, id+".auth_private")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove client authorization: %v", err)
+	}
+	return t.reloadOnionAuth(ctx)
+}
+
+// AuthorizeOnionClient authorizes a client to access the v3 onion service kept
+// in the given HiddenServiceDir, replacing any previous key of the same name.
+// Once the service has an authorized client, only those can access it.
+//
+// The client is stored in the service's authorized_clients directory, and tor
+// reloads them right away, publishing a new descriptor. Onion services created
+// via ADD_ONION (e.g. ListenOnion) don't support client authorization.
+func (t *Tor) AuthorizeOnionClient(ctx context.Context, dir string, name string, key OnionAuthPublicKey) error {
+	if err := validateOnionClient(name); err != nil {
+		return err
+	}
+	clients := filepath.Join(dir, "authorized_clients")
+	if err := os.MkdirAll(clients, 0700); err != nil {
+		return fmt.Errorf("failed to create authorized clients directory: %v", err)
+	}
+	entry := fmt.Sprintf("descriptor:x25519:%s\n", key)
+	if err := ioutil.WriteFile(filepath.Join(clients, name+".auth"), []byte(entry), 0600); err != nil {
+		return fmt.Errorf("failed to store authorized client: %v", err)
+	}
+	return t.reloadOnionAuth(ctx)
+}
+
+// RevokeOnionClient revokes the access of a client authorized via
+// AuthorizeOnionClient to the v3 onion service kept in the given HiddenServiceDir.
+// Revoking the last client opens up the service to everyone.
+func (t *Tor) RevokeOnionClient(ctx context.Context, dir string, name string) error {
+	if err := validateOnionClient(name); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, "authorized_clients", name+".auth")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove authorized client: %v", err)
+	}
+	return t.reloadOnionAuth(ctx)
+}
+
+// onionAuthDir returns the ClientOnionAuthDir of tor, configuring one within the
+// data directory if none is set.
+func (t *Tor) onionAuthDir(ctx context.Context) (string, error) {
+	conf, err := t.ctrl.GetConf(ctx, "ClientOnionAuthDir")
+	if err != nil {
+		return "", err
+	}
+	if dirs := conf["ClientOnionAuthDir"]; len(dirs) > 0 && dirs[0] != "" {
+		return dirs[0], nil
+	}
+	dir := filepath.Join(t.datadir, "onion-auth")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create client authorization directory: %v", err)
+	}
+	if err := t.ctrl.SetConf(ctx, control.ConfEntry{Key: "ClientOnionAuthDir", Value: dir}); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// reloadOnionAuth makes tor reload the client authorization keys of both its
+// onion services and the ones it accesses. Tor does so whenever its configuration
+// is changed, so ClientOnionAuthDir is set to its current value, leaving all else
+// untouched (unlike a reload signal that drops the changes made via SETCONF).
+func (t *Tor) reloadOnionAuth(ctx context.Context) error {
+	conf, err := t.ctrl.GetConf(ctx, "ClientOnionAuthDir")
+	if err != nil {
+		return err
+	}
+	if dirs := conf["ClientOnionAuthDir"]; len(dirs) > 0 {
+		return t.ctrl.SetConf(ctx, control.ConfEntry{Key: "ClientOnionAuthDir", Value: dirs[0]})
+	}
+	return t.ctrl.ResetConfDefaults(ctx, "ClientOnionAuthDir")
+}
+
+// onionServiceID validates a v3 onion address, returning it without the .onion
+// suffix.
+func onionServiceID(onion string) (string, error) {
+	if _, err := ParseOnionAddress(onion); err != nil {
+		return "", fmt.Errorf("invalid v3 onion address %q: %v", onion, err)
+	}
+	return strings.TrimSuffix(strings.ToLower(onion), ".onion"), nil
+}
+
+// validateOnionClient checks that a client name is usable as the name of its key
+// file.
+func validateOnionClient(name string) error {
+	if name == "" {
+		return errors.New("empty onion client name")
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("invalid onion client name: %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Test vector of RFC 7748 section 6.1 (Alice's keys), base32 encoded as in tor's
+// client authorization files.
+const (
+	testOnionAuthKey       = "O4DW2CTTDCSX2PAWYFZFDMTGIXPUYL4H5PAJSKVRO752KHNZFQVA"
+	testOnionAuthPublicKey = "QUQPACMJGCTVI5ELPXOLIPXXLIG36OQNEY4BV5HLUSUY5KU3JZVA"
+)
+
+// Onion address of the RFC 8032 section 7.1 test 1 public key, as derived by tor's
+// hs_build_address unit test.
+const testOnionAddress = "25njqamcweflpvkl73j4szahhihoc4xt3ktcgjnpaingr5yhkenl5sid"
+
+// Tests that client authorization keys derive their x25519 public keys, and that
+// they are encoded and parsed back the way tor stores them.
+func TestOnionAuthKey(t *testing.T) {
+	key, err := ParseOnionAuthKey(testOnionAuthKey)
+	if err != nil {
+		t.Fatalf("failed to parse private key: %v", err)
+	}
+	pub, err := ParseOnionAuthPublicKey(testOnionAuthPublicKey)
+	if err != nil {
+		t.Fatalf("failed to parse public key: %v", err)
+	}
+	if have := key.Public(); have != pub {
+		t.Errorf("public key mismatch: have %s, want %s", have, pub)
+	}
+	if have := key.String(); have != testOnionAuthKey {
+		t.Errorf("private key encoding mismatch: have %s, want %s", have, testOnionAuthKey)
+	}
+	if have := pub.String(); have != testOnionAuthPublicKey {
+		t.Errorf("public key encoding mismatch: have %s, want %s", have, testOnionAuthPublicKey)
+	}
+	// Generated keys need to be clamped and survive a round trip, in any case
+	for i := 0; i < 16; i++ {
+		key, err := GenerateOnionAuthKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		if key[0]&7 != 0 || key[31]&128 != 0 || key[31]&64 == 0 {
+			t.Errorf("generated key not clamped: %x", key[:])
+		}
+		for _, enc := range []string{key.String(), strings.ToLower(key.String()), " " + key.String() + "\n"} {
+			if parsed, err := ParseOnionAuthKey(enc); err != nil || parsed != key {
+				t.Errorf("%q: round trip mismatch: have %s (%v), want %s", enc, parsed, err, key)
+			}
+		}
+		pub := key.Public()
+		if parsed, err := ParseOnionAuthPublicKey(pub.String()); err != nil || parsed != pub {
+			t.Errorf("%s: public round trip mismatch: have %s (%v)", pub, parsed, err)
+		}
+	}
+}
+
+// Tests that client authorization keys of the wrong length or with characters
+// outside tor's base32 alphabet are rejected.
+func TestOnionAuthKeyInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		testOnionAuthKey[:51],         // Truncated
+		testOnionAuthKey + "A",        // Overlong
+		testOnionAuthKey + "AAAAAAAA", // Decodes to 37 bytes
+		testOnionAuthKey[:48],         // Decodes to 30 bytes
+		"0" + testOnionAuthKey[1:],    // Digit outside the alphabet
+		"1" + testOnionAuthKey[1:],    // Digit outside the alphabet
+		testOnionAuthKey[:51] + "8",   // Digit outside the alphabet
+		testOnionAuthKey[:20] + "=" + testOnionAuthKey[21:], // Padding
+		testOnionAuthKey[:20] + " " + testOnionAuthKey[21:], // Inner whitespace
+	}
+	for _, enc := range tests {
+		if key, err := ParseOnionAuthKey(enc); err == nil {
+			t.Errorf("%q: invalid private key accepted: %s", enc, key)
+		}
+		if key, err := ParseOnionAuthPublicKey(enc); err == nil {
+			t.Errorf("%q: invalid public key accepted: %s", enc, key)
+		}
+	}
+}
+
+// Tests that onion addresses are validated and normalized into the service IDs
+// naming the client authorization files.
+func TestOnionServiceID(t *testing.T) {
+	tests := []struct {
+		onion string
+		id    string
+	}{
+		{testOnionAddress, testOnionAddress},
+		{testOnionAddress + ".onion", testOnionAddress},
+		{strings.ToUpper(testOnionAddress) + ".ONION", testOnionAddress},
+		{"", ""},
+		{testOnionAddress[:55], ""},
+		{testOnionAddress[:55] + "a", ""}, // Bad checksum
+		{"expyuzz4wqqyqhjn", ""},          // v2 address
+		{testOnionAddress + ".onion.", ""},
+		{"../" + testOnionAddress[3:], ""},
+	}
+	for _, tt := range tests {
+		id, err := onionServiceID(tt.onion)
+		switch {
+		case tt.id == "" && err == nil:
+			t.Errorf("%q: invalid address accepted: %s", tt.onion, id)
+		case tt.id != "" && err != nil:
+			t.Errorf("%q: valid address rejected: %v", tt.onion, err)
+		case id != tt.id:
+			t.Errorf("%q: service ID mismatch: have %q, want %q", tt.onion, id, tt.id)
+		}
+	}
+}
+
+// Tests that only client names usable as file names are accepted.
+func TestValidateOnionClient(t *testing.T) {
+	for _, name := range []string{"alice", "Bob", "client-1", "client_2", "0"} {
+		if err := validateOnionClient(name); err != nil {
+			t.Errorf("%q: valid name rejected: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "../alice", "a/b", "alice.auth", "al ice", "alice\n", "ålice"} {
+		if err := validateOnionClient(name); err == nil {
+			t.Errorf("%q: invalid name accepted", name)
+		}
+	}
+}
+
+// onionAuthLogs starts tor with an onion service in the given directory, logging
+// the rendezvous domain at info level to collect the client authorization logs,
+// tagged with their severity.
+func onionAuthLogs(t *testing.T, dir string) (*Tor, func() []string) {
+	var (
+		logs []string
+		lock sync.Mutex
+	)
+	tor, err := Start(context.Background(), &Config{
+		Args: []string{
+			"--DisableNetwork", "1",
+			"--HiddenServiceDir", dir,
+			"--HiddenServicePort", "80 127.0.0.1:8080",
+		},
+		LogHandler: func(severity LogSeverity, domain LogDomain, msg string) {
+			if strings.Contains(msg, "client authorization") || strings.Contains(msg, "Client authorization") {
+				lock.Lock()
+				logs = append(logs, "["+severity.String()+"] "+msg)
+				lock.Unlock()
+			}
+		},
+		LogLevel:        LogWarn,
+		LogDomainLevels: map[LogDomain]LogSeverity{LogRend: LogInfo},
+	})
+	if err != nil {
+		t.Fatalf("failed to start tor: %v", err)
+	}
+	return tor, func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), logs...)
+	}
+}
+
+// Tests that the client authorization files are written in tor's formats, and
+// that tor loads them without complaints, both for the services it accesses and
+// the ones it hosts.
+func TestOnionAuthLoadedByTor(t *testing.T) {
+	root, err := ioutil.TempDir("", "libtor-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "service")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatalf("failed to create service directory: %v", err)
+	}
+	tor, logs := onionAuthLogs(t, dir)
+	defer tor.Close()
+
+	key, err := ParseOnionAuthKey(testOnionAuthKey)
+	if err != nil {
+		t.Fatalf("failed to parse private key: %v", err)
+	}
+	// Register the credentials of a service and check tor's .auth_private format
+	if err := tor.AddOnionAuth(context.Background(), strings.ToUpper(testOnionAddress)+".onion", key); err != nil {
+		t.Fatalf("failed to add client authorization: %v", err)
+	}
+	authdir, err := tor.onionAuthDir(context.Background())
+	if err != nil {
+		t.Fatalf("failed to retrieve client authorization directory: %v", err)
+	}
+	blob, err := ioutil.ReadFile(filepath.Join(authdir, testOnionAddress+".auth_private"))
+	if err != nil {
+		t.Fatalf("failed to read client authorization: %v", err)
+	}
+	if have, want := string(blob), testOnionAddress+":descriptor:x25519:"+testOnionAuthKey+"\n"; have != want {
+		t.Errorf("client authorization mismatch: have %q, want %q", have, want)
+	}
+	// Authorize a client of the hosted service and check tor's .auth format
+	if err := tor.AuthorizeOnionClient(context.Background(), dir, "alice", key.Public()); err != nil {
+		t.Fatalf("failed to authorize client: %v", err)
+	}
+	blob, err = ioutil.ReadFile(filepath.Join(dir, "authorized_clients", "alice.auth"))
+	if err != nil {
+		t.Fatalf("failed to read authorized client: %v", err)
+	}
+	if have, want := string(blob), "descriptor:x25519:"+testOnionAuthPublicKey+"\n"; have != want {
+		t.Errorf("authorized client mismatch: have %q, want %q", have, want)
+	}
+	// Ensure tor loaded both files without warnings
+	loaded := map[string]bool{
+		testOnionAddress + ".auth_private": false,
+		"alice.auth":                       false,
+	}
+	for _, msg := range logs() {
+		if !strings.HasPrefix(msg, "[info] ") {
+			t.Errorf("tor complained about client authorization: %s", msg)
+			continue
+		}
+		// Tor prefixes the messages with the function logging them
+		if i := strings.Index(msg, "(): "); i >= 0 {
+			msg = msg[i+4:]
+		}
+		if strings.HasPrefix(msg, "Loaded a client authorization key file ") {
+			loaded[strings.TrimSuffix(strings.TrimPrefix(msg, "Loaded a client authorization key file "), ".")] = true
+		}
+	}
+	for file, ok := range loaded {
+		if !ok {
+			t.Errorf("tor didn't load %s, logs: %q", file, logs())
+		}
+	}
+}
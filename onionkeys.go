@@ -0,0 +1,113 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cretz/bine/torutil"
+	"github.com/cretz/bine/torutil/ed25519"
+)
+
+// Names of the files of a v3 onion service within its HiddenServiceDir.
+const (
+	onionSecretKeyFile = "hs_ed25519_secret_key"
+	onionPublicKeyFile = "hs_ed25519_public_key"
+	onionHostnameFile  = "hostname"
+)
+
+// Type strings and tag of tor's key files holding v3 onion service keys.
+const (
+	onionSecretKeyType = "ed25519v1-secret"
+	onionPublicKeyType = "ed25519v1-public"
+	onionKeyTag        = "type0"
+)
+
+// ReadOnionKey reads the identity key of a v3 onion service from its
+// HiddenServiceDir, as written by tor. If the public key or hostname files are
+// also present, they are checked to match the secret key.
+func ReadOnionKey(dir string) (ed25519.KeyPair, error) {
+	secret, err := readTaggedKey(filepath.Join(dir, onionSecretKeyFile), onionSecretKeyType, 64)
+	if err != nil {
+		return nil, err
+	}
+	key := ed25519.PrivateKey(secret).KeyPair()
+
+	public, err := readTaggedKey(filepath.Join(dir, onionPublicKeyFile), onionPublicKeyType, 32)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	case !bytes.Equal(public, key.PublicKey()):
+		return nil, fmt.Errorf("onion public key doesn't match the secret key in %s", dir)
+	}
+	hostname, err := ioutil.ReadFile(filepath.Join(dir, onionHostnameFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	case strings.TrimSpace(string(hostname)) != torutil.OnionServiceIDFromV3PublicKey(key.PublicKey())+".onion":
+		return nil, fmt.Errorf("onion hostname doesn't match the secret key in %s", dir)
+	}
+	return key, nil
+}
+
+// WriteOnionKey writes the identity key of a v3 onion service into a directory
+// usable as its HiddenServiceDir, along with the public key and hostname files
+// the same way tor does. The directory is created if missing, accessible only
+// to the user running the process as tor requires.
+func WriteOnionKey(dir string, key ed25519.KeyPair) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create onion service directory: %v", err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return fmt.Errorf("failed to restrict onion service directory: %v", err)
+	}
+	if err := writeTaggedKey(filepath.Join(dir, onionSecretKeyFile), onionSecretKeyType, key.PrivateKey()); err != nil {
+		return err
+	}
+	if err := writeTaggedKey(filepath.Join(dir, onionPublicKeyFile), onionPublicKeyType, key.PublicKey()); err != nil {
+		return err
+	}
+	hostname := torutil.OnionServiceIDFromV3PublicKey(key.PublicKey()) + ".onion\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, onionHostnameFile), []byte(hostname), 0600); err != nil {
+		return fmt.Errorf("failed to write onion hostname: %v", err)
+	}
+	return nil
+}
+
+// taggedKeyHeader returns the 32 byte header of tor's key files, holding the
+// type string and the tag of the key, padded with zeroes.
+func taggedKeyHeader(typestring string) []byte {
+	header := make([]byte, 32)
+	copy(header, "== "+typestring+": "+onionKeyTag+" ==")
+	return header
+}
+
+// readTaggedKey reads a key of the given type and length from one of tor's key
+// files, requiring the tag of the v3 onion service keys.
+func readTaggedKey(path string, typestring string, size int) ([]byte, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) != 32+size || !bytes.Equal(blob[:32], taggedKeyHeader(typestring)) {
+		return nil, fmt.Errorf("invalid %s key file: %s", typestring, path)
+	}
+	return blob[32:], nil
+}
+
+// writeTaggedKey writes a key of the given type into one of tor's key files.
+func writeTaggedKey(path string, typestring string, key []byte) error {
+	blob := append(taggedKeyHeader(typestring), key...)
+	if err := ioutil.WriteFile(path, blob, 0600); err != nil {
+		return fmt.Errorf("failed to write %s key file: %v", typestring, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cretz/bine/torutil"
+)
+
+// startOnionService starts tor with a v3 onion service in the given directory,
+// for it to load or generate the service's keys.
+func startOnionService(t *testing.T, dir string) {
+	tor, err := Start(context.Background(), &Config{Args: []string{
+		"--DisableNetwork", "1",
+		"--HiddenServiceDir", dir,
+		"--HiddenServicePort", "80 127.0.0.1:8080",
+	}})
+	if err != nil {
+		t.Fatalf("failed to start tor: %v", err)
+	}
+	if err := tor.Close(); err != nil {
+		t.Fatalf("failed to stop tor: %v", err)
+	}
+}
+
+// Tests that tor accepts the onion service keys written by WriteOnionKey, and
+// derives the same onion address from them.
+func TestWriteOnionKeyLoadedByTor(t *testing.T) {
+	root, err := ioutil.TempDir("", "libtor-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	key, err := GenerateOnionKey()
+	if err != nil {
+		t.Fatalf("failed to generate onion key: %v", err)
+	}
+	dir := filepath.Join(root, "service")
+	if err := WriteOnionKey(dir, key); err != nil {
+		t.Fatalf("failed to write onion key: %v", err)
+	}
+	// Drop the hostname for tor to report it anew from the secret key
+	if err := os.Remove(filepath.Join(dir, onionHostnameFile)); err != nil {
+		t.Fatalf("failed to remove hostname: %v", err)
+	}
+	startOnionService(t, dir)
+
+	hostname, err := ioutil.ReadFile(filepath.Join(dir, onionHostnameFile))
+	if err != nil {
+		t.Fatalf("tor didn't report the hostname: %v", err)
+	}
+	if have, want := strings.TrimSpace(string(hostname)), torutil.OnionServiceIDFromV3PublicKey(key.PublicKey())+".onion"; have != want {
+		t.Errorf("hostname mismatch: have %s, want %s", have, want)
+	}
+}
+
+// Tests that the onion service keys generated by tor are read by ReadOnionKey,
+// and written back by WriteOnionKey exactly as tor did.
+func TestReadOnionKeyGeneratedByTor(t *testing.T) {
+	root, err := ioutil.TempDir("", "libtor-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "generated")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatalf("failed to create service directory: %v", err)
+	}
+	startOnionService(t, dir)
+
+	key, err := ReadOnionKey(dir)
+	if err != nil {
+		t.Fatalf("failed to read tor's onion key: %v", err)
+	}
+	copied := filepath.Join(root, "copied")
+	if err := WriteOnionKey(copied, key); err != nil {
+		t.Fatalf("failed to write onion key: %v", err)
+	}
+	for _, file := range []string{onionSecretKeyFile, onionPublicKeyFile, onionHostnameFile} {
+		want, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("failed to read tor's %s: %v", file, err)
+		}
+		have, err := ioutil.ReadFile(filepath.Join(copied, file))
+		if err != nil {
+			t.Fatalf("failed to read written %s: %v", file, err)
+		}
+		if !bytes.Equal(have, want) {
+			t.Errorf("%s mismatch: have %x, want %x", file, have, want)
+		}
+	}
+}
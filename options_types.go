@@ -0,0 +1,73 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AutoBool is a tristate tor option, which apart from being enabled or disabled
+// can also be left to tor to decide.
+type AutoBool string
+
+const (
+	AutoBoolAuto  AutoBool = "auto" // Let tor decide whether to enable the option
+	AutoBoolTrue  AutoBool = "1"    // Force enable the option
+	AutoBoolFalse AutoBool = "0"    // Force disable the option
+)
+
+// PortLine is a single configuration line of a port option (e.g. SocksPort).
+type PortLine struct {
+	Address string   // Port, address:port, unix:path, "auto" or "0" to disable
+	Flags   []string // Isolation and other flags of the port (e.g. IsolateDestAddr)
+}
+
+// String formats the port line as tor expects it in its configuration.
+func (p PortLine) String() string {
+	return strings.Join(append([]string{p.Address}, p.Flags...), " ")
+}
+
+// Bool returns a pointer to a bool value, to set an optional boolean option.
+func Bool(v bool) *bool { return &v }
+
+// Int returns a pointer to an int value, to set an optional integer option.
+func Int(v int) *int { return &v }
+
+// Uint64 returns a pointer to a uint64 value, to set an optional memory or large
+// integer option.
+func Uint64(v uint64) *uint64 { return &v }
+
+// Float64 returns a pointer to a float64 value, to set an optional real option.
+func Float64(v float64) *float64 { return &v }
+
+// Duration returns a pointer to a time.Duration value, to set an optional time
+// interval option.
+func Duration(v time.Duration) *time.Duration { return &v }
+
+// formatBool converts a boolean option into tor's representation.
+func formatBool(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+// formatMemunit converts a memory size option into tor's representation.
+func formatMemunit(v uint64) string {
+	return strconv.FormatUint(v, 10) + " bytes"
+}
+
+// formatInterval converts a time interval option into tor's representation. Tor
+// only supports second granularity, so any fraction is truncated.
+func formatInterval(v time.Duration) string {
+	return strconv.FormatInt(int64(v/time.Second), 10) + " seconds"
+}
+
+// formatMsecInterval converts a millisecond time interval option into tor's
+// representation.
+func formatMsecInterval(v time.Duration) string {
+	return strconv.FormatInt(int64(v/time.Millisecond), 10) + " msec"
+}
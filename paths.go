@@ -0,0 +1,97 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"sync"
+
+	"github.com/ipsn/go-libtor/libtor"
+)
+
+// PathPosition is a position in the path of a new circuit.
+type PathPosition int
+
+// Positions in the path of a new circuit a path selector is consulted for.
+const (
+	PathEntry  PathPosition = libtor.PathEntry  // First hop, only if entry guards are disabled
+	PathMiddle PathPosition = libtor.PathMiddle // Intermediate hops
+	PathExit   PathPosition = libtor.PathExit   // Last hop, including rendezvous points
+)
+
+// PathCandidate is a relay tor considers for a position in the path of a new
+// circuit, having passed all of tor's own constraints.
+type PathCandidate struct {
+	Relay
+	Address string  // IPv4 address of the relay's ORPort, empty if unknown
+	Country string  // Lowercase two-letter country code, empty if unknown
+	Weight  float64 // Bandwidth weight tor would choose the relay with
+	Veto    bool    // Whether to keep tor from choosing the relay
+}
+
+// PathSelector weighs the candidates tor considers for a position in the path of
+// a new circuit with the given purpose (e.g. GENERAL or HS_CLIENT_REND), by
+// changing their weights or vetoing them. Tor then chooses among the candidates
+// left, proportionally to their weights.
+type PathSelector func(position PathPosition, purpose string, candidates []PathCandidate)
+
+// SetPathSelector plugs a selector into tor's own path selection, or unplugs the
+// current one if nil. The candidates are the relays left after tor applied its
+// constraints (families, subnets, exclusions, required flags), so the selector
+// can only narrow them down. Entry guards are still chosen from tor's persistent
+// guard set, the selector is only consulted for the entry if they are disabled.
+// The exits of the dedicated circuits of exit constrained dials are chosen via
+// the selector too, with the CONTROLLER purpose, among the relays satisfying the
+// constraints.
+//
+// If the selector vetoes all the candidates, tor may consult it again for the
+// same position with a broader set: entries and middles with tor's uptime,
+// capacity, guard and address preferences relaxed, exits without having to
+// handle the ports tor predicts will be needed (which tor then stops predicting).
+// Only if those are vetoed too does tor fail to build the circuit, retrying later
+// on. Without a selector, tor's path selection is unchanged.
+//
+// The selector is invoked synchronously from tor's main loop, blocking tor until
+// it returns. It must not block on tor.
+func (t *Tor) SetPathSelector(selector PathSelector) error {
+	t.paths.lock.Lock()
+	t.paths.selector = selector
+	t.paths.lock.Unlock()
+
+	return t.proc.EnablePaths(selector != nil)
+}
+
+// pathHook relays the path selection of tor to the selector of the user.
+type pathHook struct {
+	selector PathSelector
+	lock     sync.RWMutex
+}
+
+// handle converts the candidates tor considers for a path position to their Go
+// counterparts, running them through the selector.
+func (h *pathHook) handle(position int, purpose string, candidates []libtor.PathCandidate) {
+	h.lock.RLock()
+	selector := h.selector
+	h.lock.RUnlock()
+
+	if selector == nil {
+		return
+	}
+	nodes := make([]PathCandidate, len(candidates))
+	for i, candidate := range candidates {
+		nodes[i] = PathCandidate{
+			Relay:   Relay{Fingerprint: candidate.Fingerprint, Nickname: candidate.Nickname},
+			Address: candidate.Address,
+			Weight:  candidate.Weight,
+		}
+		if candidate.Country != "??" {
+			nodes[i].Country = candidate.Country
+		}
+	}
+	selector(PathPosition(position), purpose, nodes)
+
+	for i := range candidates {
+		candidates[i].Weight = nodes[i].Weight
+		candidates[i].Veto = nodes[i].Veto
+	}
+}
@@ -0,0 +1,253 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipsn/go-libtor/control"
+)
+
+// addrmapWait is the time to wait for the address mapping event of a resolution
+// after tor already answered it, before giving up on its TTL.
+const addrmapWait = time.Second
+
+// Resolver resolves names through the embedded tor using its SOCKS RESOLVE and
+// RESOLVE_PTR extensions, so no DNS queries leave the local machine. Its lookup
+// methods mirror the ones of net.Resolver.
+//
+// Tor answers the forward lookups with a single address, preferring IPv4.
+type Resolver struct {
+	tor *Tor
+
+	// Isolation is the isolation key of the lookups that don't have one set in
+	// their context via WithIsolation. Empty means no isolation.
+	Isolation string
+}
+
+// Resolver returns a resolver looking names up through the embedded tor.
+func (t *Tor) Resolver() *Resolver {
+	return &Resolver{tor: t}
+}
+
+// Resolve looks up the address of a host, returning it along with the TTL of the
+// answer. The TTL is zero if tor did not report it, as is the case for literal
+// addresses.
+func (r *Resolver) Resolve(ctx context.Context, host string) (net.IP, time.Duration, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, 0, nil
+	}
+	answer, ttl, err := r.resolve(ctx, socksCmdResolve, host, strings.ToLower(host))
+	if err != nil {
+		return nil, 0, err
+	}
+	ip := net.ParseIP(answer)
+	if ip == nil {
+		return nil, 0, &net.DNSError{Err: "invalid address in answer: " + answer, Name: host, Server: "tor"}
+	}
+	return ip, ttl, nil
+}
+
+// ResolvePTR looks up the hostname of an address, returning it along with the TTL
+// of the answer. The TTL is zero if tor did not report it.
+func (r *Resolver) ResolvePTR(ctx context.Context, addr string) (string, time.Duration, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "", 0, &net.DNSError{Err: "unrecognized address", Name: addr, Server: "tor"}
+	}
+	return r.resolve(ctx, socksCmdResolvePTR, ip.String(), "REVERSE["+ip.String()+"]")
+}
+
+// LookupHost looks up the given host, returning its address.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	ip, _, err := r.Resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return []string{ip.String()}, nil
+}
+
+// LookupIPAddr looks up the given host, returning its address.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ip, _, err := r.Resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return []net.IPAddr{{IP: ip}}, nil
+}
+
+// LookupAddr performs a reverse lookup for the given address, returning the name
+// mapping to it.
+func (r *Resolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	name, _, err := r.ResolvePTR(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return []string{name}, nil
+}
+
+// resolve runs a SOCKS resolve request through tor, picking the TTL of the answer
+// from the address mapping event tor emits for the mapped name.
+func (r *Resolver) resolve(ctx context.Context, command byte, name string, mapped string) (string, time.Duration, error) {
+	key := isolation(ctx)
+	if key == "" {
+		key = r.Isolation
+	}
+	conn, _, err := r.tor.proc.SocksConn()
+	if err != nil {
+		return "", 0, &net.DNSError{Err: err.Error(), Name: name, Server: "tor"}
+	}
+	defer conn.Close()
+
+	// Watch the address mappings before requesting the resolution
+	lookup, err := r.tor.addrmaps.watch(ctx, mapped)
+	if err != nil {
+		return "", 0, &net.DNSError{Err: err.Error(), Name: name, Server: "tor"}
+	}
+	defer r.tor.addrmaps.unwatch(lookup)
+
+	answer, err := handshake(ctx, conn, command, name, 0, key)
+	if err != nil {
+		return "", 0, dnsError(name, err)
+	}
+	// Tor maps the name before answering, but the event may lag behind
+	timeout := time.NewTimer(addrmapWait)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case event := <-lookup.events:
+			// IPv6 answers are mapped in brackets
+			args, kwargs := event.Arguments()
+			if len(args) < 2 || !strings.EqualFold(strings.Trim(args[1], "[]"), answer) {
+				continue
+			}
+			expires, err := time.Parse("2006-01-02 15:04:05", kwargs["EXPIRES"])
+			if err != nil {
+				return answer, 0, nil
+			}
+			ttl := time.Until(expires).Round(time.Second)
+			if ttl < 0 {
+				ttl = 0
+			}
+			return answer, ttl, nil
+
+		case <-timeout.C:
+			return answer, 0, nil
+
+		case <-ctx.Done():
+			return answer, 0, nil
+		}
+	}
+}
+
+// addrmapWatcher delivers the address mapping events of tor to the lookups
+// waiting on them, sharing one subscription between all of them. Tor is only
+// asked for the events once the first lookup is made.
+type addrmapWatcher struct {
+	ctrl    *control.Conn
+	sub     *control.Subscription              // Subscription to the ADDRMAP events, nil until needed
+	pending map[string]map[*addrmapLookup]bool // Lookups waiting on a mapping, keyed by lowercase name
+	lock    sync.Mutex
+}
+
+// addrmapLookup is a lookup waiting on the address mapping of a name.
+type addrmapLookup struct {
+	name   string              // Lowercase name the lookup maps
+	events chan *control.Event // Channel to deliver the mappings of the name on
+}
+
+// newAddrmapWatcher creates a watcher for the address mappings reported on the
+// control connection.
+func newAddrmapWatcher(ctrl *control.Conn) *addrmapWatcher {
+	return &addrmapWatcher{
+		ctrl:    ctrl,
+		pending: make(map[string]map[*addrmapLookup]bool),
+	}
+}
+
+// watch registers a lookup for the address mappings of a name, subscribing to
+// them if no earlier lookup did yet.
+func (w *addrmapWatcher) watch(ctx context.Context, name string) (*addrmapLookup, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.sub == nil {
+		events := make(chan *control.Event, 16)
+		sub, err := w.ctrl.Subscribe(ctx, events, "ADDRMAP")
+		if err != nil {
+			return nil, err
+		}
+		w.sub = sub
+		go w.loop(events)
+	}
+	lookup := &addrmapLookup{
+		name:   strings.ToLower(name),
+		events: make(chan *control.Event, 4),
+	}
+	if w.pending[lookup.name] == nil {
+		w.pending[lookup.name] = make(map[*addrmapLookup]bool)
+	}
+	w.pending[lookup.name][lookup] = true
+	return lookup, nil
+}
+
+// unwatch drops a lookup, stopping the delivery of the mappings to it.
+func (w *addrmapWatcher) unwatch(lookup *addrmapLookup) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.pending[lookup.name], lookup)
+	if len(w.pending[lookup.name]) == 0 {
+		delete(w.pending, lookup.name)
+	}
+}
+
+// loop delivers the address mapping events to the lookups waiting on them, until
+// the control connection is closed. Lookups not keeping up miss the events, as
+// holding them up would hold up all the other control subscribers too.
+func (w *addrmapWatcher) loop(events chan *control.Event) {
+	for {
+		select {
+		case event := <-events:
+			args, _ := event.Arguments()
+			if len(args) < 1 {
+				continue
+			}
+			w.lock.Lock()
+			for lookup := range w.pending[strings.ToLower(args[0])] {
+				select {
+				case lookup.events <- event:
+				default:
+				}
+			}
+			w.lock.Unlock()
+
+		case <-w.ctrl.Closed():
+			return
+		}
+	}
+}
+
+// dnsError converts a failed SOCKS resolve request into a DNS error.
+func dnsError(name string, err error) error {
+	derr := &net.DNSError{Err: err.Error(), Name: name, Server: "tor"}
+	switch err {
+	case context.DeadlineExceeded:
+		derr.IsTimeout = true
+	case SocksHostUnreachable: // tor's resolve failure
+		derr.Err, derr.IsNotFound = "no such host", true
+	case SocksTTLExpired: // tor's stream timeout
+		derr.IsTimeout, derr.IsTemporary = true, true
+	default:
+		if _, ok := err.(SocksError); ok {
+			derr.IsTemporary = true
+		}
+	}
+	return derr
+}
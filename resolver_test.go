@@ -0,0 +1,97 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipsn/go-libtor/control"
+)
+
+// Tests that the address mappings of a single ADDRMAP subscription, requested
+// on the first lookup only, are delivered to the lookups waiting on them.
+func TestAddrmapWatcher(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	requests := make(chan string, 4)
+	go func() {
+		reader := bufio.NewReader(server)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				close(requests)
+				return
+			}
+			requests <- strings.TrimSuffix(line, "\r\n")
+			server.Write([]byte("250 OK\r\n"))
+		}
+	}()
+	ctrl := control.NewConn(client)
+	defer ctrl.Close()
+
+	watcher := newAddrmapWatcher(ctrl)
+	first, err := watcher.watch(context.Background(), "Example.com")
+	if err != nil {
+		t.Fatalf("failed to watch first lookup: %v", err)
+	}
+	second, err := watcher.watch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("failed to watch second lookup: %v", err)
+	}
+	other, err := watcher.watch(context.Background(), "REVERSE[1.2.3.4]")
+	if err != nil {
+		t.Fatalf("failed to watch reverse lookup: %v", err)
+	}
+	if req := <-requests; req != "SETEVENTS ADDRMAP" {
+		t.Fatalf("subscription mismatch: have %q, want %q", req, "SETEVENTS ADDRMAP")
+	}
+	// Map both names, checking that each lookup gets only its own mapping
+	server.Write([]byte("650 ADDRMAP example.com 93.184.216.34 \"2018-11-05 10:00:00\" EXPIRES=\"2018-11-05 10:00:00\" CACHED=\"NO\"\r\n"))
+	server.Write([]byte("650 ADDRMAP REVERSE[1.2.3.4] example.org \"2018-11-05 10:00:00\" EXPIRES=\"2018-11-05 10:00:00\" CACHED=\"NO\"\r\n"))
+
+	for i, lookup := range []*addrmapLookup{first, second, other} {
+		want := "93.184.216.34"
+		if lookup == other {
+			want = "example.org"
+		}
+		select {
+		case event := <-lookup.events:
+			if args, _ := event.Arguments(); len(args) < 2 || args[1] != want {
+				t.Errorf("lookup %d: mapping mismatch: have %v, want %s", i, args, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("lookup %d: mapping not delivered", i)
+		}
+	}
+	// Drop a lookup and ensure it's not delivered to anymore
+	watcher.unwatch(first)
+	server.Write([]byte("650 ADDRMAP example.com 93.184.216.35 \"2018-11-05 10:00:00\" EXPIRES=\"2018-11-05 10:00:00\" CACHED=\"NO\"\r\n"))
+
+	select {
+	case <-second.events:
+	case <-time.After(time.Second):
+		t.Fatalf("mapping not delivered to remaining lookup")
+	}
+	select {
+	case event := <-first.events:
+		t.Errorf("mapping delivered to dropped lookup: %v", event.Text)
+	default:
+	}
+	watcher.unwatch(second)
+	watcher.unwatch(other)
+	if len(watcher.pending) != 0 {
+		t.Errorf("pending lookups leaked: %v", watcher.pending)
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("unexpected request: %q", req)
+	default:
+	}
+}
@@ -0,0 +1,153 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// SOCKS5 protocol constants, including tor's extensions (socks-extensions.txt).
+const (
+	socksVersion = 0x05
+
+	socksAuthNone     = 0x00
+	socksAuthPassword = 0x02
+	socksAuthRejected = 0xff
+
+	socksCmdConnect    = 0x01
+	socksCmdResolve    = 0xf0
+	socksCmdResolvePTR = 0xf1
+
+	socksAddrIPv4   = 0x01
+	socksAddrDomain = 0x03
+	socksAddrIPv6   = 0x04
+)
+
+// socksRequest runs a SOCKS5 request over an established connection to tor's
+// SOCKS port, returning the bound address tor replied with: the answer for the
+// resolve commands. Hostnames are passed to tor as they are, never resolved
+// locally.
+//
+// If isolation is not empty, it is sent as the SOCKS username and password, so
+// tor isolates the stream from the ones with other credentials.
+func socksRequest(conn net.Conn, command byte, host string, port int, isolation string) (string, error) {
+	// Negotiate the authentication method, offering only the one we need
+	method := byte(socksAuthNone)
+	if isolation != "" {
+		method = socksAuthPassword
+	}
+	if _, err := conn.Write([]byte{socksVersion, 1, method}); err != nil {
+		return "", err
+	}
+	var reply [2]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return "", err
+	}
+	if reply[0] != socksVersion {
+		return "", fmt.Errorf("unexpected SOCKS version: %d", reply[0])
+	}
+	if reply[1] != method {
+		return "", errors.New("SOCKS authentication method rejected")
+	}
+	if method == socksAuthPassword {
+		// Credentials are limited to 255 bytes, hash longer isolation keys
+		if len(isolation) > 255 {
+			hash := sha256.Sum256([]byte(isolation))
+			isolation = hex.EncodeToString(hash[:])
+		}
+		auth := []byte{0x01, byte(len(isolation))}
+		auth = append(auth, isolation...)
+		auth = append(auth, byte(len(isolation)))
+		auth = append(auth, isolation...)
+
+		if _, err := conn.Write(auth); err != nil {
+			return "", err
+		}
+		if _, err := io.ReadFull(conn, reply[:]); err != nil {
+			return "", err
+		}
+		if reply[1] != 0x00 {
+			return "", errors.New("SOCKS authentication failed")
+		}
+	}
+	// Send the request itself, with literal addresses kept as is
+	req := []byte{socksVersion, command, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(append(req, socksAddrIPv4), ip4...)
+		} else {
+			req = append(append(req, socksAddrIPv6), ip...)
+		}
+	} else {
+		if len(host) == 0 || len(host) > 255 {
+			return "", fmt.Errorf("invalid SOCKS target host: %q", host)
+		}
+		req = append(append(req, socksAddrDomain, byte(len(host))), host...)
+	}
+	req = append(req, byte(port>>8), byte(port))
+
+	if _, err := conn.Write(req); err != nil {
+		return "", err
+	}
+	// Read the reply and the bound address
+	var head [4]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil {
+		return "", err
+	}
+	if head[0] != socksVersion {
+		return "", fmt.Errorf("unexpected SOCKS version: %d", head[0])
+	}
+	// Tor leaves the address of failure replies zeroed, type included
+	if head[1] != 0x00 && head[3] == 0x00 {
+		return "", SocksError(head[1])
+	}
+	var addr []byte
+	switch head[3] {
+	case socksAddrIPv4:
+		addr = make([]byte, net.IPv4len)
+	case socksAddrIPv6:
+		addr = make([]byte, net.IPv6len)
+	case socksAddrDomain:
+		var size [1]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return "", err
+		}
+		addr = make([]byte, size[0])
+	default:
+		return "", fmt.Errorf("unknown SOCKS address type: %d", head[3])
+	}
+	if _, err := io.ReadFull(conn, addr); err != nil {
+		return "", err
+	}
+	var bport [2]byte
+	if _, err := io.ReadFull(conn, bport[:]); err != nil {
+		return "", err
+	}
+	if head[1] != 0x00 {
+		return "", SocksError(head[1])
+	}
+	if head[3] == socksAddrDomain {
+		return string(addr), nil
+	}
+	return net.IP(addr).String(), nil
+}
+
+// splitHostPort splits a network address into host and numeric port. Named
+// ports are looked up in the local services database, hosts are left alone.
+func splitHostPort(addr string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := net.LookupPort("tcp", portStr)
+	if err != nil {
+		return "", 0, err
+	}
+	return host, port, nil
+}
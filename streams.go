@@ -0,0 +1,577 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipsn/go-libtor/control"
+)
+
+// locateTimeout is the time allowed for looking up the countries of a circuit's
+// relays.
+const locateTimeout = 10 * time.Second
+
+// controlTimeout is the time allowed for the control commands managing circuits
+// and stream attachments.
+const controlTimeout = 10 * time.Second
+
+// Hop is a relay of a circuit, along with the country it's located in.
+type Hop struct {
+	Relay
+	Country string // Lowercase two-letter country code, empty if unknown
+}
+
+// ConnInfo is what tor reported about the stream of a dialed connection.
+type ConnInfo struct {
+	StreamID  uint64 // Global identifier of the stream
+	CircuitID uint64 // Circuit the stream was last attached to, 0 if never
+	Path      []Hop  // Relays of the circuit, the exit (or rendezvous point) last
+	Read      uint64 // Bytes tor delivered to the connection
+	Written   uint64 // Bytes tor received from the connection
+}
+
+// Conn is a connection dialed through tor, exposing what tor reported about
+// its stream. It is what the Dialer returns as net.Conn.
+type Conn struct {
+	net.Conn
+
+	tracker *connTracker
+	record  *connRecord
+}
+
+// Info returns what tor reported about the connection's stream so far. It keeps
+// being updated until tor closes the stream, which happens shortly after the
+// connection is closed.
+//
+// The countries of the relays are looked up in the background once the stream
+// is attached, so they may be missing right after dialing.
+func (c *Conn) Info() *ConnInfo {
+	c.tracker.lock.Lock()
+	defer c.tracker.lock.Unlock()
+
+	info := &ConnInfo{
+		StreamID:  c.record.stream,
+		CircuitID: c.record.circuit,
+		Read:      c.record.read,
+		Written:   c.record.written,
+	}
+	if c.record.path != nil {
+		info.Path = append([]Hop(nil), c.record.path...)
+	}
+	return info
+}
+
+// connTracker follows the streams of the connections handed to tor over
+// socketpairs, recording what happened to the ones of the watched connections.
+//
+// It is fed straight from tor's main loop, which emits the events of a stream
+// before replying to its SOCKS request, so a connection's record is up to date
+// by the time its reply arrives.
+//
+// Once a connection is pinned to a circuit, the tracker takes over attaching the
+// streams from tor, handing the ones not pinned back to it (or to the attacher
+// set by the user to choose their circuits). As control commands
+// can't be issued from tor's main loop, the attachments are queued up for a
+// background goroutine.
+type connTracker struct {
+	ctrl *control.Conn // Control connection to look up relay locations with
+
+	conns     map[uint64]*connRecord // Watched connections by socketpair identifier
+	streams   map[uint64]*connRecord // Watched connections by stream identifier
+	circuits  map[uint64][]Relay     // Paths of the open circuits by identifier
+	countries map[string]string      // Countries of the relays by fingerprint
+
+	launches  map[uint64]*circuitBuild     // Circuits launched by the tracker, until built or failed
+	launching int                          // Number of launches awaiting tor's reply
+	early     map[uint64]*CircEvent        // Outcomes of circuits seen while launches await replies
+	dedicated map[string]*dedicatedCircuit // Circuits of the exit constrained connections
+
+	attaching bool                             // Whether tor leaves the streams for the tracker to attach
+	pins      int                              // Number of dials and connections pinned to circuits
+	attaches  []attachment                     // Stream attachments waiting to be sent to tor
+	wakeup    chan struct{}                    // Notification for the attacher about queued attachments
+	chooser   func(stream *StreamEvent) uint64 // User callback choosing the circuits of unpinned streams
+
+	setup    sync.Mutex // Lock serializing taking over stream attachment
+	attacher sync.Once  // Starter of the attacher goroutine
+	lock     sync.Mutex
+}
+
+// attachment is a stream to attach to a circuit, or to close.
+type attachment struct {
+	stream  uint64       // Identifier of the stream
+	circuit uint64       // Circuit to attach the stream to, 0 for tor to choose
+	close   bool         // Whether to close the stream instead of attaching it
+	reason  EndReason    // END reason to close the stream with
+	event   *StreamEvent // Event of an unpinned stream, for the user to choose its circuit
+}
+
+// circuitBuild is a circuit launched by the tracker, waiting to be built.
+type circuitBuild struct {
+	id    uint64        // Identifier of the circuit, 0 until launched
+	done  chan struct{} // Closed when the circuit is built or failed
+	built time.Time     // Time the circuit was built at
+	err   error         // Failure launching or building the circuit
+}
+
+// connRecord is what tor reported about the stream of a watched connection.
+type connRecord struct {
+	conn    uint64     // Socketpair identifier of the connection
+	stream  uint64     // Identifier of the connection's stream, 0 until known
+	target  string     // Target address and port of the stream
+	reason  EndReason  // END reason the stream failed or closed with
+	remote  bool       // Whether the END reason came from the exit or service
+	onion   OnionError // Last descriptor fetch failure of the targeted service
+	circuit uint64     // Circuit the stream was last attached to
+	pinned  uint64     // Circuit the stream must be attached to, 0 if any
+	path    []Hop      // Relays of the circuit the stream was last attached to
+	read    uint64     // Bytes tor wrote to the connection
+	written uint64     // Bytes tor read from the connection
+}
+
+// newConnTracker creates a tracker and subscribes it to the events of tor.
+func newConnTracker(bus *eventBus, ctrl *control.Conn) (*connTracker, error) {
+	tracker := &connTracker{
+		ctrl:      ctrl,
+		conns:     make(map[uint64]*connRecord),
+		streams:   make(map[uint64]*connRecord),
+		circuits:  make(map[uint64][]Relay),
+		countries: make(map[string]string),
+		launches:  make(map[uint64]*circuitBuild),
+		early:     make(map[uint64]*CircEvent),
+		dedicated: make(map[string]*dedicatedCircuit),
+		wakeup:    make(chan struct{}, 1),
+	}
+	types := []EventType{EventCirc, EventStream, EventStreamBandwidth, EventHSDesc}
+	if _, err := bus.subscribeFunc(tracker.handle, types...); err != nil {
+		return nil, err
+	}
+	return tracker, nil
+}
+
+// watch starts recording the stream of a connection, until tor closes it. If the
+// circuit is not 0, the stream is pinned to it, which needs the tracker to have
+// taken over stream attachment.
+func (t *connTracker) watch(id uint64, circuit uint64) *connRecord {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	record := &connRecord{conn: id, pinned: circuit}
+	t.conns[id] = record
+	return record
+}
+
+// forget stops recording the stream of a connection, returning what was recorded
+// about it.
+func (t *connTracker) forget(record *connRecord) connRecord {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.drop(record)
+	if record.stream != 0 {
+		delete(t.streams, record.stream)
+	}
+	return *record
+}
+
+// drop stops watching a connection, releasing its pin if it had one. The lock
+// must be held.
+func (t *connTracker) drop(record *connRecord) {
+	if t.conns[record.conn] != record {
+		return
+	}
+	delete(t.conns, record.conn)
+	if record.pinned != 0 {
+		t.release()
+	}
+}
+
+// handle processes an event of tor, running on its main loop.
+func (t *connTracker) handle(event Event) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch ev := event.(type) {
+	case *CircEvent:
+		switch ev.Status {
+		case "EXTENDED", "BUILT":
+			t.circuits[ev.ID] = ev.Path
+		case "FAILED", "CLOSED":
+			delete(t.circuits, ev.ID)
+			t.retire(ev.ID)
+		}
+		switch ev.Status {
+		case "BUILT", "FAILED", "CLOSED":
+			if build := t.launches[ev.ID]; build != nil {
+				delete(t.launches, ev.ID)
+				build.finish(ev)
+			} else if _, ok := t.early[ev.ID]; !ok && t.launching > 0 {
+				t.early[ev.ID] = ev
+			}
+		}
+	case *StreamEvent:
+		record := t.streams[ev.ID]
+		if record == nil && (ev.Status == "NEW" || ev.Status == "NEWRESOLVE") {
+			// Streams are attributed to connections by their source address
+			var id uint64
+			if _, err := fmt.Sscanf(ev.SourceAddr, "<socketpair:%d>", &id); err == nil {
+				if record = t.conns[id]; record != nil {
+					record.stream, record.target = ev.ID, ev.Target
+					t.streams[ev.ID] = record
+				}
+			}
+		}
+		if t.attaching {
+			t.schedule(ev, record)
+		}
+		if record == nil {
+			return
+		}
+		if ev.CircID != 0 && ev.CircID != record.circuit {
+			record.circuit, record.path = ev.CircID, nil
+			if path, ok := t.circuits[ev.CircID]; ok {
+				record.path = make([]Hop, len(path))
+
+				var unknown bool
+				for i, relay := range path {
+					country, ok := t.countries[relay.Fingerprint]
+					record.path[i] = Hop{Relay: relay, Country: country}
+					unknown = unknown || !ok
+				}
+				if unknown {
+					go t.locate(record, ev.CircID)
+				}
+			}
+		}
+		switch ev.Status {
+		case "DETACHED", "FAILED":
+			// Detached streams are retried, the last failure is what counts
+			if ev.Reason == "END" && ev.RemoteReason != "" {
+				record.reason, record.remote = EndReason(ev.RemoteReason), true
+			} else if ev.Reason != "" {
+				record.reason, record.remote = EndReason(ev.Reason), false
+			}
+		case "CLOSED":
+			if record.reason == "" && ev.Reason != "" {
+				record.reason = EndReason(ev.Reason)
+			}
+			// The stream is gone, only its connection may still look at it
+			delete(t.streams, ev.ID)
+			t.drop(record)
+		}
+	case *StreamBandwidthEvent:
+		// Tor reads what the connection writes and the other way around
+		if record := t.streams[ev.ID]; record != nil {
+			record.read += ev.Written
+			record.written += ev.Read
+		}
+	case *HSDescEvent:
+		for _, record := range t.conns {
+			if onionAddress(record.target) != strings.ToLower(ev.Address) {
+				continue
+			}
+			switch ev.Action {
+			case "FAILED":
+				record.onion = OnionError(ev.Reason)
+			case "RECEIVED":
+				record.onion = ""
+			}
+		}
+	}
+}
+
+// schedule queues up the attachment of a stream left unattached by tor: pinned
+// streams go to their circuits, others are handed back to tor. Pinned streams
+// detached from their circuits are closed, as tor would retry them elsewhere.
+func (t *connTracker) schedule(ev *StreamEvent, record *connRecord) {
+	var pinned uint64
+	if record != nil {
+		pinned = record.pinned
+	}
+	switch ev.Status {
+	case "NEW", "NEWRESOLVE":
+		if pinned == 0 {
+			t.attaches = append(t.attaches, attachment{stream: ev.ID, event: ev})
+			break
+		}
+		t.attaches = append(t.attaches, attachment{stream: ev.ID, circuit: pinned})
+	case "DETACHED":
+		if pinned == 0 {
+			t.attaches = append(t.attaches, attachment{stream: ev.ID, event: ev})
+			break
+		}
+		reason := EndReason(ev.Reason)
+		if ev.Reason == "END" {
+			reason = EndReason(ev.RemoteReason)
+		}
+		t.attaches = append(t.attaches, attachment{stream: ev.ID, close: true, reason: reason})
+	default:
+		return
+	}
+	select {
+	case t.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// takeOver makes tor leave new streams unattached, for the tracker to attach them
+// to their pinned circuits or hand them back to tor.
+func (t *connTracker) takeOver() error {
+	t.setup.Lock()
+	defer t.setup.Unlock()
+
+	t.lock.Lock()
+	if t.attaching {
+		t.lock.Unlock()
+		return nil
+	}
+	// Start attaching before tor stops doing it, a few streams might get handed
+	// back to tor needlessly, but none is left behind
+	t.attaching = true
+	t.lock.Unlock()
+
+	t.attacher.Do(func() { go t.attach() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+
+	if err := t.ctrl.SetConf(ctx, control.ConfEntry{Key: "__LeaveStreamsUnattached", Value: "1"}); err != nil {
+		t.lock.Lock()
+		t.attaching = false
+		t.lock.Unlock()
+		return fmt.Errorf("failed to take over stream attachment: %v", err)
+	}
+	return nil
+}
+
+// pin takes over stream attachment for a dial pinned to a circuit. The pin is
+// passed on to the connection watched with the circuit, or released via unpin if
+// the dial fails before.
+func (t *connTracker) pin() error {
+	t.lock.Lock()
+	t.pins++
+	t.lock.Unlock()
+
+	if err := t.takeOver(); err != nil {
+		t.unpin()
+		return err
+	}
+	return nil
+}
+
+// unpin releases the pin of a dial that failed before its connection was
+// watched.
+func (t *connTracker) unpin() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.release()
+}
+
+// release drops a pin, handing stream attachment back to tor in the background
+// once nothing needs the tracker to attach streams anymore. The lock must be
+// held, as this may run on tor's main loop.
+func (t *connTracker) release() {
+	if t.pins--; t.pins == 0 && t.chooser == nil && t.attaching {
+		go t.giveBack()
+	}
+}
+
+// giveBack lets tor attach new streams on its own again, unless a pinned dial or
+// a stream attacher needs the tracker to keep attaching them.
+func (t *connTracker) giveBack() error {
+	t.setup.Lock()
+	defer t.setup.Unlock()
+
+	t.lock.Lock()
+	idle := t.attaching && t.pins == 0 && t.chooser == nil
+	t.lock.Unlock()
+
+	if !idle {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+
+	// Keep handing streams back until tor stops leaving them, so none is left
+	// behind, streams tor attaches meanwhile are rejected harmlessly
+	if err := t.ctrl.SetConf(ctx, control.ConfEntry{Key: "__LeaveStreamsUnattached", Value: "0"}); err != nil {
+		return fmt.Errorf("failed to hand back stream attachment: %v", err)
+	}
+	t.lock.Lock()
+	t.attaching = false
+	t.lock.Unlock()
+	return nil
+}
+
+// attach is the attacher goroutine, sending the queued attachments to tor until
+// the control connection is closed.
+func (t *connTracker) attach() {
+	for {
+		select {
+		case <-t.wakeup:
+		case <-t.ctrl.Closed():
+			return
+		}
+		t.lock.Lock()
+		attaches, chooser := t.attaches, t.chooser
+		t.attaches = nil
+		t.lock.Unlock()
+
+		for _, attach := range attaches {
+			if attach.event != nil && chooser != nil {
+				attach.circuit = chooser(attach.event)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+
+			stream := strconv.FormatUint(attach.stream, 10)
+			if attach.close {
+				t.ctrl.CloseStream(ctx, stream, attach.reason.code())
+			} else if err := t.ctrl.AttachStream(ctx, stream, strconv.FormatUint(attach.circuit, 10), 0); err != nil {
+				// Streams tor attached on its own are fine, but ones failing to get to
+				// their chosen circuit (or back to tor) would be left hanging
+				if cerr, ok := err.(*control.Error); attach.circuit != 0 || !ok || cerr.Code != 555 {
+					t.ctrl.CloseStream(ctx, stream, EndDestroy.code())
+				}
+			}
+			cancel()
+		}
+	}
+}
+
+// launch sends an EXTENDCIRCUIT command with the given arguments, tracking the
+// circuit tor starts building until it's built or failed.
+func (t *connTracker) launch(build *circuitBuild, args string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+
+	// Circuits may complete before their launch is replied to, catch those too
+	t.lock.Lock()
+	t.launching++
+	t.lock.Unlock()
+
+	var id uint64
+	res, err := t.ctrl.Request(ctx, "EXTENDCIRCUIT %s", args)
+	if err == nil {
+		fields := strings.Fields(res.Text())
+		if len(fields) != 2 || fields[0] != "EXTENDED" {
+			err = fmt.Errorf("malformed circuit extension reply: %s", res.Text())
+		} else if id, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+			err = fmt.Errorf("malformed circuit identifier: %v", err)
+		}
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err == nil {
+		build.id = id
+		if ev, ok := t.early[id]; ok {
+			build.finish(ev)
+		} else {
+			t.launches[id] = build
+		}
+	}
+	if t.launching--; t.launching == 0 {
+		t.early = make(map[uint64]*CircEvent)
+	}
+	return err
+}
+
+// finish completes the build of a circuit with the event of its outcome.
+func (b *circuitBuild) finish(ev *CircEvent) {
+	switch {
+	case ev.Status == "BUILT":
+		b.built = time.Now()
+	case ev.RemoteReason != "":
+		b.err = &CircuitError{Reason: ev.RemoteReason, Remote: true}
+	default:
+		b.err = &CircuitError{Reason: ev.Reason}
+	}
+	close(b.done)
+}
+
+// locate looks up the countries of the relays on the path of a stream's circuit,
+// filling them in if the stream is still attached to the same circuit.
+func (t *connTracker) locate(record *connRecord, circuit uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), locateTimeout)
+	defer cancel()
+
+	t.lock.Lock()
+	hops := append([]Hop(nil), record.path...)
+	t.lock.Unlock()
+
+	for i, hop := range hops {
+		if hop.Country != "" || hop.Fingerprint == "" {
+			continue
+		}
+		t.lock.Lock()
+		country, ok := t.countries[hop.Fingerprint]
+		t.lock.Unlock()
+
+		if !ok {
+			country = t.country(ctx, hop.Fingerprint)
+			if ctx.Err() != nil {
+				return
+			}
+			t.lock.Lock()
+			t.countries[hop.Fingerprint] = country
+			t.lock.Unlock()
+		}
+		t.lock.Lock()
+		if record.circuit == circuit && i < len(record.path) {
+			record.path[i].Country = country
+		}
+		t.lock.Unlock()
+	}
+}
+
+// country looks up the country of a relay from its consensus address, returning
+// empty if it's unknown (e.g. tor has no GeoIP database).
+func (t *connTracker) country(ctx context.Context, fingerprint string) string {
+	infos, err := t.ctrl.GetInfo(ctx, "ns/id/"+fingerprint)
+	if err != nil {
+		return ""
+	}
+	// The router line ends in the address and the OR and directory ports
+	for _, line := range strings.Split(infos["ns/id/"+fingerprint], "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "r" {
+			continue
+		}
+		addr := fields[len(fields)-3]
+		infos, err := t.ctrl.GetInfo(ctx, "ip-to-country/"+addr)
+		if err != nil {
+			return ""
+		}
+		if country := infos["ip-to-country/"+addr]; country != "??" {
+			return country
+		}
+		return ""
+	}
+	return ""
+}
+
+// onionAddress returns the onion address of a stream target without the .onion
+// suffix and any subdomains, or empty if the target is not an onion service.
+func onionAddress(target string) string {
+	host := strings.ToLower(target)
+	if idx := strings.LastIndexByte(host, ':'); idx >= 0 {
+		host = host[:idx]
+	}
+	if !strings.HasSuffix(host, ".onion") {
+		return ""
+	}
+	host = strings.TrimSuffix(host, ".onion")
+	if idx := strings.LastIndexByte(host, '.'); idx >= 0 {
+		host = host[idx+1:]
+	}
+	return host
+}
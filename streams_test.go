@@ -0,0 +1,59 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Tests that tor is only told to leave streams unattached while connections are
+// pinned to circuits or a stream attacher is set.
+func TestStreamAttachmentHandBack(t *testing.T) {
+	tor, err := Start(context.Background(), &Config{Args: []string{"--DisableNetwork", "1"}})
+	if err != nil {
+		t.Fatalf("failed to start tor: %v", err)
+	}
+	defer tor.Close()
+
+	// Pin a dial to a circuit that doesn't exist, which needs to fail
+	ctx, cancel := context.WithTimeout(WithCircuit(context.Background(), 1<<31), 10*time.Second)
+	defer cancel()
+
+	if conn, err := tor.Dialer().DialContext(ctx, "tcp", "example.com:80"); err == nil {
+		conn.Close()
+		t.Fatalf("dial over missing circuit succeeded")
+	}
+	waitLeaveStreamsUnattached(t, tor, "0")
+
+	// Set and remove a stream attacher, toggling the attachment back and forth
+	if err := tor.SetStreamAttacher(func(*StreamEvent) uint64 { return 0 }); err != nil {
+		t.Fatalf("failed to set stream attacher: %v", err)
+	}
+	waitLeaveStreamsUnattached(t, tor, "1")
+
+	if err := tor.SetStreamAttacher(nil); err != nil {
+		t.Fatalf("failed to remove stream attacher: %v", err)
+	}
+	waitLeaveStreamsUnattached(t, tor, "0")
+}
+
+// waitLeaveStreamsUnattached waits for tor's __LeaveStreamsUnattached option to
+// take the given value, as the attachment is handed back in the background.
+func waitLeaveStreamsUnattached(t *testing.T, tor *Tor, want string) {
+	t.Helper()
+
+	var have []string
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		conf, err := tor.Control().GetConf(context.Background(), "__LeaveStreamsUnattached")
+		if err != nil {
+			t.Fatalf("failed to query stream attachment: %v", err)
+		}
+		if have = conf["__LeaveStreamsUnattached"]; len(have) == 1 && have[0] == want {
+			return
+		}
+	}
+	t.Fatalf("__LeaveStreamsUnattached mismatch: have %q, want %q", have, want)
+}
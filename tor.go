@@ -0,0 +1,235 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/cretz/bine/process"
+	"github.com/ipsn/go-libtor/control"
+	"github.com/ipsn/go-libtor/libtor"
+)
+
+// Config contains the parameters to start an embedded tor instance with.
+type Config struct {
+	Options // Typed tor options, serialized before the raw arguments
+
+	DataDir string   // Data directory of tor, a temporary one is used if empty
+	Torrc   *Torrc   // In-memory torrc files to use, nil to read them from disk
+	Args    []string // Additional raw command line arguments to pass to tor
+
+	LogHandler      LogHandler                // Handler to deliver tor's logs to instead of stdout
+	LogLevel        LogSeverity               // Minimum severity to deliver to the handler (default notice)
+	LogDomainLevels map[LogDomain]LogSeverity // Minimum severities overriding LogLevel for some domains
+}
+
+// embeddedProcess is the API of libtor's embedded processes, extending the one
+// required by bine.
+type embeddedProcess interface {
+	process.Process
+	io.Closer
+
+	SetTorrc(torrc, defaults string) error
+	SetLogHandler(handler func(severity int, domain uint32, msg string), severity string) error
+	VerifyConfig() (string, error)
+	SetEventHandler(handler func(event interface{})) error
+	SetEventMask(mask uint32) error
+	SetPathHandler(handler func(position int, purpose string, candidates []libtor.PathCandidate)) error
+	EnablePaths(enabled bool) error
+	SocksConn() (net.Conn, uint64, error)
+}
+
+// Tor is an embedded tor instance, owning the process running within the Go
+// binary, its control connection and its data directory.
+type Tor struct {
+	proc     embeddedProcess
+	ctrl     *control.Conn
+	events   *eventBus
+	conns    *connTracker
+	paths    *pathHook
+	addrmaps *addrmapWatcher
+	datadir  string
+	tempdir  bool // Whether the data directory is temporary, to clean up on close
+}
+
+// Start launches an embedded tor instance and connects to its control port. The
+// context governs the lifetime of the instance: cancelling it shuts tor down.
+//
+// Start returns once tor has started processing control commands, so configuration
+// errors are reported here, not later from Wait.
+func Start(ctx context.Context, config *Config) (*Tor, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if config == nil {
+		config = new(Config)
+	}
+	t := &Tor{datadir: config.DataDir}
+	if t.datadir == "" {
+		dir, err := ioutil.TempDir("", "libtor")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %v", err)
+		}
+		t.datadir, t.tempdir = dir, true
+	}
+	// Create the embedded process along with its owning control connection
+	proc, err := newProcess(ctx, config, t.datadir)
+	if err != nil {
+		t.cleanup()
+		return nil, err
+	}
+	events, err := newEventBus(proc)
+	if err != nil {
+		proc.Close()
+		t.cleanup()
+		return nil, err
+	}
+	t.paths = new(pathHook)
+	if err := proc.SetPathHandler(t.paths.handle); err != nil {
+		proc.Close()
+		t.cleanup()
+		return nil, err
+	}
+	conn, err := proc.EmbeddedControlConn()
+	if err != nil {
+		proc.Close()
+		t.cleanup()
+		return nil, err
+	}
+	if err := proc.Start(); err != nil {
+		conn.Close()
+		proc.Close()
+		t.cleanup()
+		return nil, err
+	}
+	t.proc, t.ctrl, t.events = proc, control.NewConn(conn), events
+	t.addrmaps = newAddrmapWatcher(t.ctrl)
+
+	// Ensure tor actually came up, reporting its exit status if not
+	if _, err := t.ctrl.GetInfo(ctx, "version"); err != nil {
+		if exit := t.Close(); exit != nil {
+			return nil, exit
+		}
+		return nil, fmt.Errorf("failed to contact embedded tor: %v", err)
+	}
+	if t.conns, err = newConnTracker(events, t.ctrl); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Control returns the owning control connection of the embedded tor. Closing it
+// shuts tor down.
+func (t *Tor) Control() *control.Conn {
+	return t.ctrl
+}
+
+// DataDir returns the data directory used by the embedded tor.
+func (t *Tor) DataDir() string {
+	return t.datadir
+}
+
+// Wait blocks until the embedded tor terminates, returning its exit status.
+func (t *Tor) Wait() error {
+	return t.proc.Wait()
+}
+
+// Close shuts the embedded tor down, waits for it to terminate and removes its
+// data directory if it was a temporary one.
+func (t *Tor) Close() error {
+	t.events.close()
+	t.ctrl.Close()
+	err := t.proc.Close()
+
+	t.cleanup()
+	return err
+}
+
+// newProcess creates an embedded tor process from the configuration, running it
+// in the given data directory.
+func newProcess(ctx context.Context, config *Config, datadir string) (embeddedProcess, error) {
+	var torrc, defaults string
+	if config.Torrc != nil {
+		var err error
+		if torrc, defaults, err = config.Torrc.expand(); err != nil {
+			return nil, err
+		}
+	}
+	args := append([]string{"--DataDirectory", datadir}, config.Options.Args()...)
+	args = append(args, config.Args...)
+
+	// Dialing doesn't need a SocksPort, so don't expose one unless asked to
+	if !argsSetOption(args, "SocksPort") && !torrcSetsOption(torrc, "SocksPort") && !torrcSetsOption(defaults, "SocksPort") {
+		args = append(args, "--SocksPort", "0")
+	}
+	proc, err := libtor.Creator.New(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	embedded := proc.(embeddedProcess)
+	if config.Torrc != nil {
+		if err := embedded.SetTorrc(torrc, defaults); err != nil {
+			embedded.Close()
+			return nil, err
+		}
+	}
+	if handler := config.LogHandler; handler != nil {
+		severity, err := logSeverityConfig(config.LogLevel, config.LogDomainLevels)
+		if err != nil {
+			embedded.Close()
+			return nil, err
+		}
+		err = embedded.SetLogHandler(func(severity int, domain uint32, msg string) {
+			handler(LogSeverity(severity), LogDomain(domain)&allLogDomains, msg)
+		}, severity)
+		if err != nil {
+			embedded.Close()
+			return nil, err
+		}
+	}
+	return embedded, nil
+}
+
+// argsSetOption returns whether a tor command line sets the given option, in any
+// of the forms tor accepts (e.g. "--SocksPort", "SocksPort" or "+SocksPort").
+func argsSetOption(args []string, name string) bool {
+	for _, arg := range args {
+		if optionKey(arg, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// torrcSetsOption returns whether the contents of a torrc set the given option.
+func torrcSetsOption(torrc string, name string) bool {
+	for _, line := range strings.Split(torrc, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && optionKey(fields[0], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// optionKey returns whether a command line or torrc key refers to the given
+// option, ignoring the dashes and the +/ modifiers tor allows before it.
+func optionKey(key string, name string) bool {
+	key = strings.TrimLeft(key, "-")
+	key = strings.TrimLeft(key, "+/")
+	return strings.EqualFold(key, name)
+}
+
+// cleanup removes the data directory if it was created by libtor.
+func (t *Tor) cleanup() {
+	if t.tempdir {
+		os.RemoveAll(t.datadir)
+	}
+}
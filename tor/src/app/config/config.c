@@ -866,6 +866,15 @@ static config_line_t *global_cmdline_options = NULL;
 static config_line_t *global_cmdline_only_options = NULL;
 /** Boolean: Have we parsed the command line? */
 static int have_parsed_cmdline = 0;
+/** Error message of the most recent failure to load the startup configuration.
+ * It is deliberately kept across tor_free_all(), so that embedders can retrieve
+ * it after tor_run_main() returned. */
+static char *last_options_error = NULL;
+/** Contents of the torrc and defaults torrc provided by an embedding
+ * application, to be used instead of the files on disk. Owned by the
+ * tor_main_configuration_t, which outlives the configuration. */
+static const char *torrc_contents = NULL;
+static const char *torrc_defaults_contents = NULL;
 /** Contents of most recently read DirPortFrontPage file. */
 static char *global_dirfrontpagecontents = NULL;
 /** List of port_cfg_t for all configured ports. */
@@ -1073,6 +1082,7 @@ config_free_all(void)
   cleanup_protocol_warning_severity_level();
 
   have_parsed_cmdline = 0;
+  torrc_contents = torrc_defaults_contents = NULL;
   libevent_initialized = 0;
 }
 
@@ -5176,6 +5186,8 @@ options_init_from_torrc(int argc, char **argv)
   config_line_t *p_index = NULL;
   config_line_t *cmdline_only_options = NULL;
 
+  tor_free(last_options_error);
+
   /* Go through command-line variables */
   if (! have_parsed_cmdline) {
     /* Or we could redo the list every time we pass this place.
@@ -5273,7 +5285,10 @@ options_init_from_torrc(int argc, char **argv)
     cf_defaults = tor_strdup("");
     cf = tor_strdup("");
   } else {
-    cf_defaults = load_torrc_from_disk(cmdline_only_options, 1);
+    if (torrc_defaults_contents)
+      cf_defaults = tor_strdup(torrc_defaults_contents);
+    else
+      cf_defaults = load_torrc_from_disk(cmdline_only_options, 1);
 
     const config_line_t *f_line = config_line_find(cmdline_only_options,
                                                    "-f");
@@ -5281,7 +5296,9 @@ options_init_from_torrc(int argc, char **argv)
     const int read_torrc_from_stdin =
     (f_line != NULL && strcmp(f_line->value, "-") == 0);
 
-    if (read_torrc_from_stdin) {
+    if (torrc_contents) {
+      cf = tor_strdup(torrc_contents);
+    } else if (read_torrc_from_stdin) {
       cf = load_torrc_from_stdin();
     } else {
       cf = load_torrc_from_disk(cmdline_only_options, 0);
@@ -5370,11 +5387,32 @@ options_init_from_torrc(int argc, char **argv)
   tor_free(cf_defaults);
   if (errmsg) {
     log_warn(LD_CONFIG,"%s", errmsg);
-    tor_free(errmsg);
+    last_options_error = errmsg;
   }
   return retval < 0 ? -1 : 0;
 }
 
+/** Return the error message of the most recent failure to load the startup
+ * configuration, or NULL if there was none. The caller takes ownership of the
+ * returned string. */
+char *
+options_take_last_error(void)
+{
+  char *msg = last_options_error;
+  last_options_error = NULL;
+  return msg;
+}
+
+/** Use <b>torrc</b> and <b>defaults</b> as the contents of the torrc and the
+ * defaults torrc instead of loading them from disk. Either may be NULL to load
+ * that file from disk as usual. The strings must outlive the configuration. */
+void
+options_set_torrc_contents(const char *torrc, const char *defaults)
+{
+  torrc_contents = torrc;
+  torrc_defaults_contents = defaults;
+}
+
 /** Load the options from the configuration in <b>cf</b>, validate
  * them for consistency and take actions based on them.
  *
@@ -7993,6 +8031,13 @@ write_configuration_file(const char *fname, const or_options_t *options)
 int
 options_save_current(void)
 {
+  /* An in-memory torrc has no file of its own to write back to, and writing
+   * it to the default torrc location would clobber an unrelated file. */
+  if (torrc_contents) {
+    log_warn(LD_CONFIG, "Not saving the configuration: the torrc was "
+             "provided by the embedding application.");
+    return -1;
+  }
   /* This fails if we can't write to our configuration file.
    *
    * If we try falling back to datadirectory or something, we have a better
@@ -68,6 +68,8 @@ void options_init(or_options_t *options);
 #define OPTIONS_DUMP_ALL 3
 char *options_dump(const or_options_t *options, int how_to_dump);
 int options_init_from_torrc(int argc, char **argv);
+char *options_take_last_error(void);
+void options_set_torrc_contents(const char *torrc, const char *defaults);
 setopt_err_t options_init_from_string(const char *cf_defaults, const char *cf,
                             int command, const char *command_arg, char **msg);
 int option_is_recognized(const char *key);
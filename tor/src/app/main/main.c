@@ -34,6 +34,7 @@
 #include "core/or/scheduler.h"
 #include "core/or/status.h"
 #include "feature/api/tor_api.h"
+#include "feature/api/tor_api_conn.h"
 #include "feature/api/tor_api_internal.h"
 #include "feature/client/addressmap.h"
 #include "feature/client/bridges.h"
@@ -51,6 +52,7 @@
 #include "feature/nodelist/authcert.h"
 #include "feature/nodelist/microdesc.h"
 #include "feature/nodelist/networkstatus.h"
+#include "feature/nodelist/node_select.h"
 #include "feature/nodelist/nodelist.h"
 #include "feature/nodelist/routerlist.h"
 #include "feature/relay/dns.h"
@@ -783,6 +785,7 @@ tor_free_all(int postfork)
   routerparse_free_all();
   ext_orport_free_all();
   control_free_all();
+  tor_api_conn_free_all();
   tor_free_getaddrinfo_cache();
   protover_free_all();
   bridges_free_all();
@@ -806,6 +809,11 @@ tor_free_all(int postfork)
 #endif
   }
   /* stuff in main.c */
+  {
+    int i;
+    for (i = 0; signal_handlers[i].signal_value >= 0; ++i)
+      tor_event_free(signal_handlers[i].signal_event);
+  }
 
   tor_mainloop_free_all();
 
@@ -1276,6 +1284,7 @@ run_tor_main_loop(void)
   monotime_init();
   timers_initialize();
   initialize_mainloop_events();
+  tor_api_conn_init();
 
   /* load the private keys, if we're supposed to have them, and set up the
    * TLS context. */
@@ -1434,6 +1443,19 @@ tor_run_main(const tor_main_configuration_t *tor_cfg)
   init_logging(0);
   monotime_init();
 
+  if (tor_cfg->log_callback) {
+    log_severity_list_t severity;
+    const char *cfg = tor_cfg->log_severity;
+    memset(&severity, 0, sizeof(severity));
+    if (parse_log_severity_config(&cfg, &severity) < 0) {
+      tor_free_all(0);
+      return -1;
+    }
+    add_permanent_callback_log(&severity, (log_callback)tor_cfg->log_callback);
+  }
+  control_set_event_callback(tor_cfg->event_callback);
+  node_select_set_path_callback(tor_cfg->path_callback);
+
   int argc = tor_cfg->argc + tor_cfg->argc_owned;
   char **argv = tor_calloc(argc, sizeof(char*));
   memcpy(argv, tor_cfg->argv, tor_cfg->argc*sizeof(char*));
@@ -1450,6 +1472,7 @@ tor_run_main(const tor_main_configuration_t *tor_cfg)
      }
   }
 #endif /* defined(NT_SERVICE) */
+  options_set_torrc_contents(tor_cfg->torrc, tor_cfg->torrc_defaults);
   {
     int init_rv = tor_init(argc, argv);
     if (init_rv) {
@@ -2263,8 +2263,11 @@ onion_pick_cpath_exit(origin_circuit_t *circ, extend_info_t *exit_ei,
       flags |= CRN_RENDEZVOUS_V3;
     if (state->onehop_tunnel)
       flags |= CRN_DIRECT_CONN;
-    const node_t *node =
-      choose_good_exit_server(circ, flags, state->is_internal);
+    const node_t *node;
+    node_select_set_path_position(TOR_PATH_EXIT,
+              circuit_purpose_to_controller_string(circ->base_.purpose));
+    node = choose_good_exit_server(circ, flags, state->is_internal);
+    node_select_set_path_position(0, NULL);
     if (!node) {
       log_warn(LD_CIRC,"Failed to choose an exit server");
       return -1;
@@ -2597,7 +2600,10 @@ choose_good_middle_server(uint8_t purpose,
     return choice;
   }
 
+  node_select_set_path_position(TOR_PATH_MIDDLE,
+                                circuit_purpose_to_controller_string(purpose));
   choice = router_choose_random_node(excluded, options->ExcludeNodes, flags);
+  node_select_set_path_position(0, NULL);
   smartlist_free(excluded);
   return choice;
 }
@@ -2652,7 +2658,10 @@ choose_good_entry_server(uint8_t purpose, cpath_build_state_t *state,
       flags |= CRN_NEED_CAPACITY;
   }
 
+  node_select_set_path_position(TOR_PATH_ENTRY,
+                                circuit_purpose_to_controller_string(purpose));
   choice = router_choose_random_node(excluded, options->ExcludeNodes, flags);
+  node_select_set_path_position(0, NULL);
   smartlist_free(excluded);
   return choice;
 }
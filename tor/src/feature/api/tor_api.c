@@ -98,6 +98,59 @@ tor_main_configuration_set_command_line(tor_main_configuration_t *cfg,
   return 0;
 }
 
+int
+tor_main_configuration_set_torrc(tor_main_configuration_t *cfg,
+                                 const char *torrc, const char *defaults)
+{
+  if (cfg == NULL)
+    return -1;
+  raw_free(cfg->torrc);
+  raw_free(cfg->torrc_defaults);
+  cfg->torrc = cfg->torrc_defaults = NULL;
+
+  if (torrc && NULL == (cfg->torrc = raw_strdup(torrc)))
+    return -1;
+  if (defaults && NULL == (cfg->torrc_defaults = raw_strdup(defaults)))
+    return -1;
+  return 0;
+}
+
+int
+tor_main_configuration_set_log_callback(tor_main_configuration_t *cfg,
+                                        tor_log_callback_t cb,
+                                        const char *severity)
+{
+  if (cfg == NULL || cb == NULL || severity == NULL)
+    return -1;
+  char *copy = raw_strdup(severity);
+  if (copy == NULL)
+    return -1;
+  raw_free(cfg->log_severity);
+  cfg->log_callback = cb;
+  cfg->log_severity = copy;
+  return 0;
+}
+
+int
+tor_main_configuration_set_event_callback(tor_main_configuration_t *cfg,
+                                          tor_event_callback_t cb)
+{
+  if (cfg == NULL || cb == NULL)
+    return -1;
+  cfg->event_callback = cb;
+  return 0;
+}
+
+int
+tor_main_configuration_set_path_callback(tor_main_configuration_t *cfg,
+                                         tor_path_callback_t cb)
+{
+  if (cfg == NULL || cb == NULL)
+    return -1;
+  cfg->path_callback = cb;
+  return 0;
+}
+
 tor_control_socket_t
 tor_main_configuration_setup_control_socket(tor_main_configuration_t *cfg)
 {
@@ -132,6 +185,9 @@ tor_main_configuration_free(tor_main_configuration_t *cfg)
   if (SOCKET_OK(cfg->owning_controller_socket)) {
     raw_closesocket(cfg->owning_controller_socket);
   }
+  raw_free(cfg->torrc);
+  raw_free(cfg->torrc_defaults);
+  raw_free(cfg->log_severity);
   raw_free(cfg);
 }
 
@@ -49,6 +49,232 @@ tor_main_configuration_t *tor_main_configuration_new(void);
 int tor_main_configuration_set_command_line(tor_main_configuration_t *cfg,
                                             int argc, char *argv[]);
 
+/**
+ * Set the contents of the torrc and the defaults torrc in <b>cfg</b>, to be
+ * used instead of reading the files from disk. Either may be NULL to load that
+ * file from disk as usual. The contents are copied.
+ *
+ * Return 0 on success, -1 on failure.
+ */
+int tor_main_configuration_set_torrc(tor_main_configuration_t *cfg,
+                                     const char *torrc,
+                                     const char *defaults);
+
+/** Callback type receiving the messages logged by tor. */
+typedef void (*tor_log_callback_t)(int severity, unsigned int domain,
+                                   const char *msg);
+
+/**
+ * Send the messages tor logs to <b>cb</b>, starting from the very first one.
+ * The <b>severity</b> selects the messages to deliver, in the same format as
+ * the severities of the Log option (e.g. "[handshake]debug notice"). It is
+ * copied.
+ *
+ * The callback may be invoked from any thread tor runs, and must not call
+ * back into tor.
+ *
+ * Return 0 on success, -1 on failure.
+ */
+int tor_main_configuration_set_log_callback(tor_main_configuration_t *cfg,
+                                            tor_log_callback_t cb,
+                                            const char *severity);
+
+/** Types of the events delivered to a tor_event_callback_t, also used as the
+ * bit positions of the event mask. */
+#define TOR_EVENT_CIRC 0
+#define TOR_EVENT_STREAM 1
+#define TOR_EVENT_ORCONN 2
+#define TOR_EVENT_BW 3
+#define TOR_EVENT_STREAM_BW 4
+#define TOR_EVENT_HS_DESC 5
+#define TOR_EVENT_GUARD 6
+#define TOR_EVENT_NEWCONSENSUS 7
+
+/** A TOR_EVENT_CIRC event: the status of an origin circuit changed. String
+ * fields are the same keywords as in the CIRC control event, NULL if the
+ * field is absent. */
+typedef struct tor_circ_event_t {
+  unsigned long long id;
+  const char *status;
+  const char *path; /* Comma separated verbose nicknames of the hops. */
+  const char *purpose;
+  const char *reason;
+  const char *remote_reason;
+} tor_circ_event_t;
+
+/** A TOR_EVENT_STREAM event: the status of a client stream changed. */
+typedef struct tor_stream_event_t {
+  unsigned long long id;
+  const char *status;
+  unsigned long long circ_id; /* 0 if the stream is not attached. */
+  const char *target; /* Address:port the stream is connecting to. */
+  const char *reason;
+  const char *remote_reason;
+  const char *source; /* Source of a REMAP: CACHE or EXIT. */
+  const char *source_addr;
+  const char *purpose;
+} tor_stream_event_t;
+
+/** A TOR_EVENT_ORCONN event: the status of an OR connection changed. */
+typedef struct tor_orconn_event_t {
+  unsigned long long id;
+  const char *target;
+  const char *status;
+  const char *reason;
+  int ncircs;
+} tor_orconn_event_t;
+
+/** A TOR_EVENT_BW event: the bytes tor transferred in the last second. */
+typedef struct tor_bw_event_t {
+  unsigned long read;
+  unsigned long written;
+} tor_bw_event_t;
+
+/** A TOR_EVENT_STREAM_BW event: the bytes a stream transferred since its
+ * last such event. */
+typedef struct tor_stream_bw_event_t {
+  unsigned long long id;
+  unsigned long read;
+  unsigned long written;
+} tor_stream_bw_event_t;
+
+/** A TOR_EVENT_HS_DESC event: progress of a hidden service descriptor
+ * fetch or upload. */
+typedef struct tor_hs_desc_event_t {
+  const char *action;
+  const char *address;
+  const char *auth_type;
+  const char *hsdir;
+  const char *desc_id;
+  const char *reason;
+} tor_hs_desc_event_t;
+
+/** A TOR_EVENT_GUARD event: the status of an entry guard changed. */
+typedef struct tor_guard_event_t {
+  const char *name;
+  const char *status;
+} tor_guard_event_t;
+
+/** A TOR_EVENT_NEWCONSENSUS event: tor is switching to a new consensus.
+ * Times are in seconds since the Unix epoch. */
+typedef struct tor_newconsensus_event_t {
+  long long valid_after;
+  long long fresh_until;
+  long long valid_until;
+  int n_relays; /* Number of relays listed in the consensus. */
+} tor_newconsensus_event_t;
+
+/** Callback type receiving the events tor emits. The <b>event</b> points to
+ * the structure matching <b>type</b>, valid only during the call. */
+typedef void (*tor_event_callback_t)(int type, const void *event);
+
+/**
+ * Send the events tor emits to <b>cb</b>, directly from tor's main loop and
+ * without going through the control protocol. Only the types enabled with
+ * tor_set_event_mask() are delivered.
+ *
+ * The callback blocks tor while it runs, and must not call back into tor.
+ *
+ * Return 0 on success, -1 on failure.
+ */
+int tor_main_configuration_set_event_callback(tor_main_configuration_t *cfg,
+                                              tor_event_callback_t cb);
+
+/**
+ * Select the event types delivered to the event callback of the running
+ * tor, as a bitmask of (1 << TOR_EVENT_*) values. May be called from any
+ * thread.
+ */
+void tor_set_event_mask(unsigned int mask);
+
+/** Positions in a new circuit's path that a tor_path_callback_t may be
+ * consulted for. */
+#define TOR_PATH_ENTRY 1
+#define TOR_PATH_MIDDLE 2
+#define TOR_PATH_EXIT 3
+
+/** A relay tor considers for a position in a new circuit's path, having
+ * passed all of tor's own constraints (families, exclusions, flags). */
+typedef struct tor_path_candidate_t {
+  char fingerprint[41]; /* Hex encoded identity digest. */
+  const char *nickname;
+  char address[48]; /* IPv4 address of the ORPort, or empty. */
+  char country[3]; /* Lowercase GeoIP country code, "??" if unknown. */
+  double weight; /* Bandwidth weight tor would choose the relay with. */
+  int veto; /* Set by the callback to keep the relay from being chosen. */
+} tor_path_candidate_t;
+
+/** Callback type weighting or vetoing the <b>n</b> <b>candidates</b> for a
+ * <b>position</b> (one of TOR_PATH_*) in the path of a circuit with the
+ * control protocol <b>purpose</b>. The callback may change the weights of
+ * the candidates, and set the veto flag of the ones not to choose. */
+typedef void (*tor_path_callback_t)(int position, const char *purpose,
+                                    tor_path_candidate_t *candidates, int n);
+
+/**
+ * Consult <b>cb</b> when tor chooses the relays of new circuits, directly
+ * from tor's main loop, once enabled with tor_set_path_callback_enabled().
+ * Entry guards are chosen from their own persistent set as usual: the
+ * callback is only consulted for the entry position if guards are disabled.
+ *
+ * The callback blocks tor while it runs, and must not call back into tor.
+ *
+ * Return 0 on success, -1 on failure.
+ */
+int tor_main_configuration_set_path_callback(tor_main_configuration_t *cfg,
+                                             tor_path_callback_t cb);
+
+/**
+ * Enable or disable consulting the path callback of the running tor. May be
+ * called from any thread.
+ */
+void tor_set_path_callback_enabled(int enabled);
+
+/** Length of a v3 onion address without the ".onion" suffix, and of the
+ * ed25519 keys it encodes. */
+#define TOR_ONION_ADDRESS_LEN 56
+#define TOR_ONION_KEY_LEN 32
+
+/** Results of the onion address functions. */
+#define TOR_ONION_OK 0
+#define TOR_ONION_MALFORMED (-1) /* Bad length or base32 encoding. */
+#define TOR_ONION_BAD_CHECKSUM (-2)
+#define TOR_ONION_BAD_VERSION (-3)
+#define TOR_ONION_BAD_KEY (-4) /* Not a point without torsion component. */
+
+/**
+ * Build the v3 onion address (without the ".onion" suffix) of the service
+ * with the ed25519 identity <b>pubkey</b> into <b>address_out</b>, which
+ * must hold TOR_ONION_ADDRESS_LEN + 1 bytes.
+ *
+ * The onion address functions don't need a running tor, log nothing and
+ * may be called from any thread.
+ *
+ * Return TOR_ONION_OK on success, TOR_ONION_BAD_KEY on an invalid key.
+ */
+int tor_onion_build_address(const unsigned char *pubkey, char *address_out);
+
+/**
+ * Parse the lowercase v3 onion <b>address</b> (without the ".onion" suffix),
+ * checking its checksum, version and key, and put the ed25519 identity key
+ * of the service into <b>pubkey_out</b>.
+ *
+ * Return TOR_ONION_OK on success, or one of the TOR_ONION_* errors.
+ */
+int tor_onion_parse_address(const char *address, unsigned char *pubkey_out);
+
+/**
+ * Blind the ed25519 identity <b>pubkey</b> of a v3 onion service for the
+ * time period <b>period_num</b> of <b>period_length</b> minutes, putting
+ * the blinded key into <b>blinded_out</b>.
+ *
+ * Return TOR_ONION_OK on success, TOR_ONION_BAD_KEY on an invalid key.
+ */
+int tor_onion_blind_pubkey(const unsigned char *pubkey,
+                           unsigned long long period_num,
+                           unsigned long long period_length,
+                           unsigned char *blinded_out);
+
 #ifdef _WIN32
 typedef SOCKET tor_control_socket_t;
 #define INVALID_TOR_CONTROL_SOCKET INVALID_SOCKET
@@ -61,6 +287,22 @@ typedef int tor_control_socket_t;
 tor_control_socket_t tor_main_configuration_setup_control_socket(
                                           tor_main_configuration_t *cfg);
 
+/**
+ * Create a new client connection to the running Tor, without any listener.
+ * Tor adopts one end of a socketpair as if its SocksPort had accepted it,
+ * and the other end is returned, to speak SOCKS over. The caller owns the
+ * returned socket and must close it. May be called from any thread.
+ *
+ * If <b>id_out</b> is not NULL, it is set to the identifier of the new
+ * connection, which its stream events report as the "<socketpair:ID>"
+ * source address.
+ *
+ * Return INVALID_TOR_CONTROL_SOCKET if Tor is not running its main loop or
+ * the socketpair cannot be created.
+ */
+tor_control_socket_t tor_api_new_socks_connection(
+                                          unsigned long long *id_out);
+
 /**
  * Release all storage held in <b>cfg</b>.
  *
@@ -0,0 +1,216 @@
+/* Copyright (c) 2001 Matej Pfajfar.
+ * Copyright (c) 2001-2004, Roger Dingledine.
+ * Copyright (c) 2004-2006, Roger Dingledine, Nick Mathewson.
+ * Copyright (c) 2007-2019, The Tor Project, Inc. */
+/* See LICENSE for licensing information */
+
+/**
+ * \file tor_api_conn.c
+ * \brief Hand client connections to a running Tor over socketpairs.
+ *
+ * The embedding application may ask for a new client connection from any
+ * thread. We create a socketpair, queue up one end to be adopted by the main
+ * loop as if a SocksPort had just accepted it, and return the other end. No
+ * listener needs to be opened for this.
+ *
+ * Each connection is assigned an identifier, reported as its source address
+ * "<socketpair:ID>" in the stream events, so the application can tell its
+ * streams apart.
+ *
+ * Libevent is not thread-safe in Tor, so the main loop is woken up via an
+ * alert socket, the same way the worker threads report their replies.
+ **/
+
+#include "core/or/or.h"
+#include "feature/api/tor_api.h"
+#include "feature/api/tor_api_conn.h"
+#include "core/mainloop/connection.h"
+#include "core/mainloop/mainloop.h"
+#include "core/or/connection_edge.h"
+#include "lib/evloop/compat_libevent.h"
+#include "lib/net/alertsock.h"
+#include "lib/net/socket.h"
+#include "lib/net/socketpair.h"
+#include "lib/thread/threads.h"
+
+#include "core/or/entry_connection_st.h"
+#include "core/or/listener_connection_st.h"
+#include "core/or/socks_request_st.h"
+
+#include <event2/event.h>
+
+/** Lock protecting the adoption queue and event. Once initialized, it lives
+ * for the rest of the process, since other threads may still be using it. */
+static tor_mutex_t adopt_lock;
+/** True iff adopt_lock has been initialized. */
+static int adopt_lock_initialized = 0;
+/** A socket waiting to be adopted by the main loop as a client connection. */
+typedef struct adopt_request_t {
+  tor_socket_t sock;
+  unsigned long long id;
+} adopt_request_t;
+
+/** Requests waiting to be adopted by the main loop, as adopt_request_t. */
+static smartlist_t *adopt_queue = NULL;
+/** Identifier of the next client connection to create. */
+static unsigned long long adopt_next_id = 1;
+/** Sockets alerting the main loop of newly queued sockets. */
+static alert_sockets_t adopt_alert;
+/** Event adopting the queued sockets, NULL if Tor is not running. */
+static struct event *adopt_event = NULL;
+
+/** Adopt <b>sock</b> as a new client connection identified by <b>id</b>,
+ * in the same way a SOCKS listener with the default options would have
+ * accepted it. */
+static void
+adopt_socks_connection(tor_socket_t sock, unsigned long long id)
+{
+  listener_connection_t listener;
+  connection_t *conn;
+
+  if (set_socket_nonblocking(sock) < 0) {
+    tor_close_socket(sock);
+    return;
+  }
+  conn = connection_new(CONN_TYPE_AP, AF_UNIX);
+  conn->s = sock;
+  tor_addr_make_unspec(&conn->addr);
+  conn->port = 0;
+  tor_asprintf(&conn->address, "<socketpair:%llu>", id);
+
+  if (connection_add(conn) < 0) {
+    connection_free(conn);
+    return;
+  }
+  /* There is no listener to inherit from, impersonate a default one. */
+  memset(&listener, 0, sizeof(listener));
+  listener.base_.type = CONN_TYPE_AP_LISTENER;
+  listener.entry_cfg.isolation_flags = ISO_DEFAULT;
+  listener.entry_cfg.ipv4_traffic = 1;
+  listener.entry_cfg.ipv6_traffic = 1;
+  listener.entry_cfg.dns_request = 1;
+  listener.entry_cfg.onion_traffic = 1;
+  listener.entry_cfg.prefer_ipv6_virtaddr = 1;
+  /* Record resolved answers so their expiry reaches the controllers, but
+   * never use them: cached answers would leak across isolation keys. */
+  listener.entry_cfg.cache_ipv4_answers = 1;
+  listener.entry_cfg.cache_ipv6_answers = 1;
+
+  log_info(LD_NET, "New SOCKS connection adopted from the embedding "
+           "application.");
+  if (connection_init_accepted_conn(conn, &listener) < 0) {
+    if (!conn->marked_for_close)
+      connection_mark_for_close(conn);
+  }
+}
+
+/** Main loop callback: adopt all the queued sockets. */
+static void
+adopt_queued_connections_cb(evutil_socket_t sock, short events, void *arg)
+{
+  smartlist_t *queue;
+  (void)sock;
+  (void)events;
+  (void)arg;
+
+  tor_mutex_acquire(&adopt_lock);
+  adopt_alert.drain_fn(adopt_alert.read_fd);
+  queue = adopt_queue;
+  adopt_queue = smartlist_new();
+  tor_mutex_release(&adopt_lock);
+
+  SMARTLIST_FOREACH(queue, adopt_request_t *, req, {
+    adopt_socks_connection(req->sock, req->id);
+    tor_free(req);
+  });
+  smartlist_free(queue);
+}
+
+/** Start accepting client connections from the embedding application. Must
+ * be called from the main thread once the event loop is initialized. */
+void
+tor_api_conn_init(void)
+{
+  if (!adopt_lock_initialized) {
+    tor_mutex_init(&adopt_lock);
+    adopt_lock_initialized = 1;
+  }
+  tor_mutex_acquire(&adopt_lock);
+  if (!adopt_event) {
+    if (alert_sockets_create(&adopt_alert, 0) < 0) {
+      log_warn(LD_NET, "Unable to create alert sockets for adopting "
+               "client connections.");
+      tor_mutex_release(&adopt_lock);
+      return;
+    }
+    adopt_queue = smartlist_new();
+    adopt_event = tor_event_new(tor_libevent_get_base(), adopt_alert.read_fd,
+                                EV_READ|EV_PERSIST,
+                                adopt_queued_connections_cb, NULL);
+    event_add(adopt_event, NULL);
+  }
+  tor_mutex_release(&adopt_lock);
+}
+
+/** Stop accepting client connections from the embedding application, and
+ * close the ones not adopted yet. */
+void
+tor_api_conn_free_all(void)
+{
+  if (!adopt_lock_initialized)
+    return;
+
+  tor_mutex_acquire(&adopt_lock);
+  if (adopt_queue) {
+    SMARTLIST_FOREACH(adopt_queue, adopt_request_t *, req, {
+      tor_close_socket(req->sock);
+      tor_free(req);
+    });
+    smartlist_free(adopt_queue);
+  }
+  if (adopt_event) {
+    tor_event_free(adopt_event);
+    alert_sockets_close(&adopt_alert);
+  }
+  tor_mutex_release(&adopt_lock);
+}
+
+/** Return true iff client connections from the embedding application are
+ * being accepted. Must be called from the main thread. */
+int
+tor_api_conn_active(void)
+{
+  return adopt_event != NULL;
+}
+
+tor_control_socket_t
+tor_api_new_socks_connection(unsigned long long *id_out)
+{
+  tor_socket_t fds[2];
+  adopt_request_t *req;
+
+  if (!adopt_lock_initialized)
+    return INVALID_TOR_CONTROL_SOCKET;
+
+  tor_mutex_acquire(&adopt_lock);
+  if (!adopt_event) {
+    tor_mutex_release(&adopt_lock);
+    return INVALID_TOR_CONTROL_SOCKET;
+  }
+  if (tor_socketpair(AF_UNIX, SOCK_STREAM, 0, fds) < 0) {
+    tor_mutex_release(&adopt_lock);
+    return INVALID_TOR_CONTROL_SOCKET;
+  }
+  req = tor_malloc_zero(sizeof(adopt_request_t));
+  req->sock = fds[1];
+  req->id = adopt_next_id++;
+  if (id_out)
+    *id_out = req->id;
+  smartlist_add(adopt_queue, req);
+  adopt_alert.alert_fn(adopt_alert.write_fd);
+  tor_mutex_release(&adopt_lock);
+
+  /* The application's end is not tracked by Tor, it's theirs to close. */
+  tor_release_socket_ownership(fds[0]);
+  return fds[0];
+}
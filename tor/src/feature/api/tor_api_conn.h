@@ -0,0 +1,19 @@
+/* Copyright (c) 2001 Matej Pfajfar.
+ * Copyright (c) 2001-2004, Roger Dingledine.
+ * Copyright (c) 2004-2006, Roger Dingledine, Nick Mathewson.
+ * Copyright (c) 2007-2019, The Tor Project, Inc. */
+/* See LICENSE for licensing information */
+
+/**
+ * \file tor_api_conn.h
+ * \brief Header file for tor_api_conn.c.
+ **/
+
+#ifndef TOR_API_CONN_H
+#define TOR_API_CONN_H
+
+void tor_api_conn_init(void);
+void tor_api_conn_free_all(void);
+int tor_api_conn_active(void);
+
+#endif /* !defined(TOR_API_CONN_H) */
@@ -7,6 +7,7 @@
 #ifndef TOR_API_INTERNAL_H
 #define TOR_API_INTERNAL_H
 
+#include "feature/api/tor_api.h"
 #include "lib/net/nettypes.h"
 
 /* The contents of this type are private; don't mess with them from outside
@@ -24,6 +25,23 @@ struct tor_main_configuration_t {
 
   /** Socket that Tor will use as an owning control socket. Owned. */
   tor_socket_t owning_controller_socket;
+
+  /** Contents of the torrc to use instead of reading it from disk, or NULL.
+   * Owned. */
+  char *torrc;
+  /** As torrc, but for the defaults torrc. Owned. */
+  char *torrc_defaults;
+
+  /** Callback to send the log messages to, or NULL. */
+  tor_log_callback_t log_callback;
+  /** Severities of the messages to send to log_callback. Owned. */
+  char *log_severity;
+
+  /** Callback to send the events to, or NULL. */
+  tor_event_callback_t event_callback;
+
+  /** Callback weighting the candidates of path positions, or NULL. */
+  tor_path_callback_t path_callback;
 };
 
 #endif /* !defined(TOR_API_INTERNAL_H) */
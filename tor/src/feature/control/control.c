@@ -75,9 +75,11 @@
 #include "feature/nodelist/dirlist.h"
 #include "feature/nodelist/microdesc.h"
 #include "feature/nodelist/networkstatus.h"
+#include "feature/nodelist/node_select.h"
 #include "feature/nodelist/nodelist.h"
 #include "feature/nodelist/routerinfo.h"
 #include "feature/nodelist/routerlist.h"
+#include "feature/nodelist/routerset.h"
 #include "feature/relay/router.h"
 #include "feature/relay/routermode.h"
 #include "feature/relay/selftest.h"
@@ -155,6 +157,27 @@ static int disable_log_messages = 0;
 #define ANY_EVENT_IS_INTERESTING(e) \
   (!! (global_event_mask & (e)))
 
+/** Callback of the embedding application receiving events in-process, or
+ * NULL if there is none. */
+static tor_event_callback_t event_callback = NULL;
+/** Bitmask of the TOR_EVENT_* types the event callback wants. It is set
+ * from the embedding application's threads, hence the atomic accesses. */
+static unsigned int event_callback_mask = 0;
+
+/** Macro: true if the event callback is interested in events of type
+ * <b>e</b>, one of TOR_EVENT_*. */
+#define EVENT_HOOK_IS_INTERESTING(e) \
+  (event_callback && \
+   (__atomic_load_n(&event_callback_mask, __ATOMIC_RELAXED) & (1u << (e))))
+
+/** Deliver an event of type <b>type</b> to the event callback, if it's
+ * interested in it. */
+#define EVENT_HOOK_SEND(type, ev) \
+  STMT_BEGIN \
+    if (EVENT_HOOK_IS_INTERESTING(type)) \
+      event_callback((type), (ev)); \
+  STMT_END
+
 /** If we're using cookie-type authentication, how long should our cookies be?
  */
 #define AUTHENTICATION_COOKIE_LEN 32
@@ -429,7 +452,8 @@ control_any_per_second_event_enabled(void)
       EVENT_MASK_(EVENT_CIRC_BANDWIDTH_USED) |
       EVENT_MASK_(EVENT_CONN_BW) |
       EVENT_MASK_(EVENT_STREAM_BANDWIDTH_USED)
-  );
+  ) || EVENT_HOOK_IS_INTERESTING(TOR_EVENT_BW) ||
+       EVENT_HOOK_IS_INTERESTING(TOR_EVENT_STREAM_BW);
 }
 
 /* The value of 'get_bytes_read()' the previous time that
@@ -3601,6 +3625,102 @@ is_keyval_pair(const char *s)
   return strchr(s, '=') && s[0] != '$';
 }
 
+/** Helper for EXTENDCIRCUIT 0: choose an exit for a new circuit satisfying
+ * the EXITNODES, EXCLUDENODES and EXITPORT constraints among the arguments
+ * <b>args</b>, weighted by bandwidth the way tor picks its own exits for
+ * circuits of <b>purpose</b>. Set *<b>constrained</b> to whether any
+ * constraint was given, and *<b>exit</b> to the chosen exit, or NULL if no
+ * relay matches. On malformed constraints, reply with an error on
+ * <b>conn</b> and return -1; otherwise return 0. */
+static int
+control_choose_constrained_exit(control_connection_t *conn,
+                                smartlist_t *args, uint8_t purpose,
+                                int *constrained, const node_t **exit)
+{
+  const or_options_t *options = get_options();
+  const char *include = find_element_starting_with(args, 1, "EXITNODES=");
+  const char *exclude = find_element_starting_with(args, 1, "EXCLUDENODES=");
+  const char *port_str = find_element_starting_with(args, 1, "EXITPORT=");
+  routerset_t *included = NULL, *excluded = NULL;
+  smartlist_t *candidates = NULL;
+  uint16_t port = 0;
+  int ok, r = -1;
+
+  *constrained = include || exclude || port_str;
+  *exit = NULL;
+  if (!*constrained)
+    return 0;
+
+  if (port_str) {
+    port_str += strlen("EXITPORT=");
+    port = (uint16_t)tor_parse_long(port_str, 10, 1, 65535, &ok, NULL);
+    if (!ok) {
+      connection_printf_to_buf(conn, "552 Invalid exit port \"%s\"\r\n",
+                               port_str);
+      goto done;
+    }
+  }
+  if (include) {
+    include += strlen("EXITNODES=");
+    included = routerset_new();
+    if (routerset_parse(included, include, "EXITNODES") < 0) {
+      connection_printf_to_buf(conn, "552 Invalid exit nodes \"%s\"\r\n",
+                               include);
+      goto done;
+    }
+  }
+  if (exclude) {
+    exclude += strlen("EXCLUDENODES=");
+    excluded = routerset_new();
+    if (routerset_parse(excluded, exclude, "EXCLUDENODES") < 0) {
+      connection_printf_to_buf(conn, "552 Invalid excluded nodes \"%s\"\r\n",
+                               exclude);
+      goto done;
+    }
+  }
+  /* Same filters as choose_good_exit_server_general(), minus the pending
+   * streams, which a dedicated circuit doesn't care about. */
+  candidates = smartlist_new();
+  SMARTLIST_FOREACH_BEGIN(nodelist_get_list(), const node_t *, node) {
+    if (router_digest_is_me(node->identity))
+      continue;
+    if (!node_has_preferred_descriptor(node, 0))
+      continue;
+    if (!node->is_running || !node->is_valid || node->is_bad_exit)
+      continue;
+    if (node_get_purpose(node) != ROUTER_PURPOSE_GENERAL)
+      continue;
+    if (node_exit_policy_rejects_all(node))
+      continue;
+    if (routerset_contains_node(options->ExcludeExitNodesUnion_, node) ||
+        routerset_contains_node(excluded, node))
+      continue;
+    if (included && !routerset_contains_node(included, node))
+      continue;
+    if (port) {
+      addr_policy_result_t res;
+      res = compare_tor_addr_to_node_policy(NULL, port, node);
+      if (res == ADDR_POLICY_REJECTED || res == ADDR_POLICY_PROBABLY_REJECTED)
+        continue;
+    }
+    smartlist_add(candidates, (void*)node);
+  } SMARTLIST_FOREACH_END(node);
+
+  if (smartlist_len(candidates)) {
+    node_select_set_path_position(TOR_PATH_EXIT,
+                     circuit_purpose_to_controller_string(purpose));
+    *exit = node_sl_choose_by_bandwidth(candidates, WEIGHT_FOR_EXIT);
+    node_select_set_path_position(0, NULL);
+  }
+  r = 0;
+
+ done:
+  routerset_free(included);
+  routerset_free(excluded);
+  smartlist_free(candidates);
+  return r;
+}
+
 /** Called when we get an EXTENDCIRCUIT message.  Try to extend the listed
  * circuit, and report success or failure. */
 static int
@@ -3638,7 +3758,33 @@ handle_control_extendcircuit(control_connection_t *conn, uint32_t len,
     if ((smartlist_len(args) == 1) ||
         (smartlist_len(args) >= 2 && is_keyval_pair(smartlist_get(args, 1)))) {
       // "EXTENDCIRCUIT 0" || EXTENDCIRCUIT 0 foo=bar"
-      circ = circuit_launch(intended_purpose, CIRCLAUNCH_NEED_CAPACITY);
+      const node_t *exit = NULL;
+      int constrained = 0;
+
+      if (control_choose_constrained_exit(conn, args, intended_purpose,
+                                          &constrained, &exit) < 0) {
+        SMARTLIST_FOREACH(args, char *, cp, tor_free(cp));
+        smartlist_free(args);
+        goto done;
+      }
+      if (constrained && !exit) {
+        connection_write_str_to_buf(
+                       "551 No relay matches the exit constraints\r\n", conn);
+        SMARTLIST_FOREACH(args, char *, cp, tor_free(cp));
+        smartlist_free(args);
+        goto done;
+      }
+      if (exit) {
+        extend_info_t *info = extend_info_from_node(exit, 0);
+        circ = NULL;
+        if (info) {
+          circ = circuit_launch_by_extend_info(intended_purpose, info,
+                                               CIRCLAUNCH_NEED_CAPACITY);
+          extend_info_free(info);
+        }
+      } else {
+        circ = circuit_launch(intended_purpose, CIRCLAUNCH_NEED_CAPACITY);
+      }
       if (!circ) {
         connection_write_str_to_buf("551 Couldn't start circuit\r\n", conn);
       } else {
@@ -5624,8 +5770,11 @@ control_event_circuit_status(origin_circuit_t *circ, circuit_status_event_t tp,
                              int reason_code)
 {
   const char *status;
+  const char *reason_str = NULL;
   char reasons[64] = "";
-  if (!EVENT_IS_INTERESTING(EVENT_CIRCUIT_STATUS))
+  char unk_reason_buf[16];
+  if (!EVENT_IS_INTERESTING(EVENT_CIRCUIT_STATUS) &&
+      !EVENT_HOOK_IS_INTERESTING(TOR_EVENT_CIRC))
     return 0;
   tor_assert(circ);
 
@@ -5643,8 +5792,7 @@ control_event_circuit_status(origin_circuit_t *circ, circuit_status_event_t tp,
     }
 
   if (tp == CIRC_EVENT_FAILED || tp == CIRC_EVENT_CLOSED) {
-    const char *reason_str = circuit_end_reason_to_control_string(reason_code);
-    char unk_reason_buf[16];
+    reason_str = circuit_end_reason_to_control_string(reason_code);
     if (!reason_str) {
       tor_snprintf(unk_reason_buf, 16, "UNKNOWN_%d", reason_code);
       reason_str = unk_reason_buf;
@@ -5658,6 +5806,23 @@ control_event_circuit_status(origin_circuit_t *circ, circuit_status_event_t tp,
     }
   }
 
+  if (EVENT_HOOK_IS_INTERESTING(TOR_EVENT_CIRC)) {
+    int remote = reason_code > 0 && reason_code & END_CIRC_REASON_FLAG_REMOTE;
+    char *path = circuit_list_path_for_controller(circ);
+    tor_circ_event_t ev = {
+      .id = circ->global_identifier,
+      .status = status,
+      .path = path,
+      .purpose = circuit_purpose_to_controller_string(circ->base_.purpose),
+      .reason = remote ? "DESTROYED" : reason_str,
+      .remote_reason = remote ? reason_str : NULL,
+    };
+    event_callback(TOR_EVENT_CIRC, &ev);
+    tor_free(path);
+  }
+  if (!EVENT_IS_INTERESTING(EVENT_CIRCUIT_STATUS))
+    return 0;
+
   {
     char *circdesc = circuit_describe_status_for_controller(circ);
     const char *sp = strlen(circdesc) ? " " : "";
@@ -5809,9 +5974,13 @@ control_event_stream_status(entry_connection_t *conn, stream_status_event_t tp,
   origin_circuit_t *origin_circ = NULL;
   char buf[256];
   const char *purpose = "";
+  const char *reason_str = NULL;
+  const char *source = NULL;
+  char *r = NULL;
   tor_assert(conn->socks_request);
 
-  if (!EVENT_IS_INTERESTING(EVENT_STREAM_STATUS))
+  if (!EVENT_IS_INTERESTING(EVENT_STREAM_STATUS) &&
+      !EVENT_HOOK_IS_INTERESTING(TOR_EVENT_STREAM))
     return 0;
 
   if (tp == STREAM_EVENT_CLOSED &&
@@ -5839,8 +6008,7 @@ control_event_stream_status(entry_connection_t *conn, stream_status_event_t tp,
   if (reason_code && (tp == STREAM_EVENT_FAILED ||
                       tp == STREAM_EVENT_CLOSED ||
                       tp == STREAM_EVENT_FAILED_RETRIABLE)) {
-    const char *reason_str = stream_end_reason_to_control_string(reason_code);
-    char *r = NULL;
+    reason_str = stream_end_reason_to_control_string(reason_code);
     if (!reason_str) {
       tor_asprintf(&r, " UNKNOWN_%d", reason_code);
       reason_str = r;
@@ -5851,14 +6019,15 @@ control_event_stream_status(entry_connection_t *conn, stream_status_event_t tp,
     else
       tor_snprintf(reason_buf, sizeof(reason_buf),
                    " REASON=%s", reason_str);
-    tor_free(r);
   } else if (reason_code && tp == STREAM_EVENT_REMAP) {
     switch (reason_code) {
     case REMAP_STREAM_SOURCE_CACHE:
       strlcpy(reason_buf, " SOURCE=CACHE", sizeof(reason_buf));
+      source = "CACHE";
       break;
     case REMAP_STREAM_SOURCE_EXIT:
       strlcpy(reason_buf, " SOURCE=EXIT", sizeof(reason_buf));
+      source = "EXIT";
       break;
     default:
       tor_snprintf(reason_buf, sizeof(reason_buf), " REASON=UNKNOWN_%d",
@@ -5907,6 +6076,29 @@ control_event_stream_status(entry_connection_t *conn, stream_status_event_t tp,
   circ = circuit_get_by_edge_conn(ENTRY_TO_EDGE_CONN(conn));
   if (circ && CIRCUIT_IS_ORIGIN(circ))
     origin_circ = TO_ORIGIN_CIRCUIT(circ);
+
+  if (EVENT_HOOK_IS_INTERESTING(TOR_EVENT_STREAM)) {
+    /* The unknown reason is formatted with a leading space, skip it */
+    const char *reason = r ? r + 1 : reason_str;
+    int remote = reason && (reason_code & END_STREAM_REASON_FLAG_REMOTE);
+    tor_stream_event_t ev = {
+      .id = ENTRY_TO_CONN(conn)->global_identifier,
+      .status = status,
+      .circ_id = origin_circ ? origin_circ->global_identifier : 0,
+      .target = buf,
+      .reason = remote ? "END" : reason,
+      .remote_reason = remote ? reason : NULL,
+      .source = source,
+      .source_addr = addrport_buf[0] ? addrport_buf + strlen(" SOURCE_ADDR=")
+                                     : NULL,
+      .purpose = purpose[0] ? purpose + strlen(" PURPOSE=") : NULL,
+    };
+    event_callback(TOR_EVENT_STREAM, &ev);
+  }
+  tor_free(r);
+  if (!EVENT_IS_INTERESTING(EVENT_STREAM_STATUS))
+    return 0;
+
   send_control_event(EVENT_STREAM_STATUS,
                         "650 STREAM %"PRIu64" %s %lu %s%s%s%s\r\n",
                      (ENTRY_TO_CONN(conn)->global_identifier),
@@ -5954,7 +6146,8 @@ control_event_or_conn_status(or_connection_t *conn, or_conn_status_event_t tp,
   char name[128];
   char ncircs_buf[32] = {0}; /* > 8 + log10(2^32)=10 + 2 */
 
-  if (!EVENT_IS_INTERESTING(EVENT_OR_CONN_STATUS))
+  if (!EVENT_IS_INTERESTING(EVENT_OR_CONN_STATUS) &&
+      !EVENT_HOOK_IS_INTERESTING(TOR_EVENT_ORCONN))
     return 0;
 
   switch (tp)
@@ -5979,6 +6172,20 @@ control_event_or_conn_status(or_connection_t *conn, or_conn_status_event_t tp,
   }
 
   orconn_target_get_name(name, sizeof(name), conn);
+
+  if (EVENT_HOOK_IS_INTERESTING(TOR_EVENT_ORCONN)) {
+    tor_orconn_event_t ev = {
+      .id = conn->base_.global_identifier,
+      .target = name,
+      .status = status,
+      .reason = reason ? orconn_end_reason_to_control_string(reason) : NULL,
+      .ncircs = ncircs,
+    };
+    event_callback(TOR_EVENT_ORCONN, &ev);
+  }
+  if (!EVENT_IS_INTERESTING(EVENT_OR_CONN_STATUS))
+    return 0;
+
   send_control_event(EVENT_OR_CONN_STATUS,
                               "650 ORCONN %s %s%s%s%s ID=%"PRIu64"\r\n",
                               name, status,
@@ -5990,6 +6197,19 @@ control_event_or_conn_status(or_connection_t *conn, or_conn_status_event_t tp,
   return 0;
 }
 
+/** Deliver a STREAM_BW event for <b>edge_conn</b> to the event callback, if
+ * it's interested in it. */
+static void
+control_event_stream_bandwidth_hook(const edge_connection_t *edge_conn)
+{
+  tor_stream_bw_event_t ev = {
+    .id = edge_conn->base_.global_identifier,
+    .read = edge_conn->n_read,
+    .written = edge_conn->n_written,
+  };
+  EVENT_HOOK_SEND(TOR_EVENT_STREAM_BW, &ev);
+}
+
 /**
  * Print out STREAM_BW event for a single conn
  */
@@ -5998,10 +6218,16 @@ control_event_stream_bandwidth(edge_connection_t *edge_conn)
 {
   struct timeval now;
   char tbuf[ISO_TIME_USEC_LEN+1];
-  if (EVENT_IS_INTERESTING(EVENT_STREAM_BANDWIDTH_USED)) {
+  if (EVENT_IS_INTERESTING(EVENT_STREAM_BANDWIDTH_USED) ||
+      EVENT_HOOK_IS_INTERESTING(TOR_EVENT_STREAM_BW)) {
     if (!edge_conn->n_read && !edge_conn->n_written)
       return 0;
 
+    control_event_stream_bandwidth_hook(edge_conn);
+    if (!EVENT_IS_INTERESTING(EVENT_STREAM_BANDWIDTH_USED)) {
+      edge_conn->n_written = edge_conn->n_read = 0;
+      return 0;
+    }
     tor_gettimeofday(&now);
     format_iso_time_nospace_usec(tbuf, &now);
     send_control_event(EVENT_STREAM_BANDWIDTH_USED,
@@ -6022,7 +6248,8 @@ control_event_stream_bandwidth(edge_connection_t *edge_conn)
 int
 control_event_stream_bandwidth_used(void)
 {
-  if (EVENT_IS_INTERESTING(EVENT_STREAM_BANDWIDTH_USED)) {
+  if (EVENT_IS_INTERESTING(EVENT_STREAM_BANDWIDTH_USED) ||
+      EVENT_HOOK_IS_INTERESTING(TOR_EVENT_STREAM_BW)) {
     smartlist_t *conns = get_connection_array();
     edge_connection_t *edge_conn;
     struct timeval now;
@@ -6036,6 +6263,11 @@ control_event_stream_bandwidth_used(void)
         if (!edge_conn->n_read && !edge_conn->n_written)
           continue;
 
+        control_event_stream_bandwidth_hook(edge_conn);
+        if (!EVENT_IS_INTERESTING(EVENT_STREAM_BANDWIDTH_USED)) {
+          edge_conn->n_written = edge_conn->n_read = 0;
+          continue;
+        }
         tor_gettimeofday(&now);
         format_iso_time_nospace_usec(tbuf, &now);
         send_control_event(EVENT_STREAM_BANDWIDTH_USED,
@@ -6322,6 +6554,10 @@ control_event_bandwidth_used(uint32_t n_read, uint32_t n_written)
   if (n_measurements < N_BW_EVENTS_TO_CACHE)
     ++n_measurements;
 
+  if (EVENT_HOOK_IS_INTERESTING(TOR_EVENT_BW)) {
+    tor_bw_event_t ev = { .read = n_read, .written = n_written };
+    event_callback(TOR_EVENT_BW, &ev);
+  }
   if (EVENT_IS_INTERESTING(EVENT_BANDWIDTH_USED)) {
     send_control_event(EVENT_BANDWIDTH_USED,
                        "650 BW %lu %lu\r\n",
@@ -6611,6 +6847,15 @@ control_event_networkstatus_changed(smartlist_t *statuses)
 int
 control_event_newconsensus(const networkstatus_t *consensus)
 {
+  if (EVENT_HOOK_IS_INTERESTING(TOR_EVENT_NEWCONSENSUS)) {
+    tor_newconsensus_event_t ev = {
+      .valid_after = consensus->valid_after,
+      .fresh_until = consensus->fresh_until,
+      .valid_until = consensus->valid_until,
+      .n_relays = smartlist_len(consensus->routerstatus_list),
+    };
+    event_callback(TOR_EVENT_NEWCONSENSUS, &ev);
+  }
   if (!control_event_is_interesting(EVENT_NEWCONSENSUS))
     return 0;
   return control_event_networkstatus_changed_helper(
@@ -6853,7 +7098,8 @@ control_event_guard(const char *nickname, const char *digest,
 {
   char hbuf[HEX_DIGEST_LEN+1];
   base16_encode(hbuf, sizeof(hbuf), digest, DIGEST_LEN);
-  if (!EVENT_IS_INTERESTING(EVENT_GUARD))
+  if (!EVENT_IS_INTERESTING(EVENT_GUARD) &&
+      !EVENT_HOOK_IS_INTERESTING(TOR_EVENT_GUARD))
     return 0;
 
   {
@@ -6864,6 +7110,10 @@ control_event_guard(const char *nickname, const char *digest,
     } else {
       tor_snprintf(buf, sizeof(buf), "$%s~%s", hbuf, nickname);
     }
+    if (EVENT_HOOK_IS_INTERESTING(TOR_EVENT_GUARD)) {
+      tor_guard_event_t ev = { .name = buf, .status = status };
+      event_callback(TOR_EVENT_GUARD, &ev);
+    }
     send_control_event(EVENT_GUARD,
                        "650 GUARD ENTRY %s %s\r\n", buf, status);
   }
@@ -7450,6 +7700,27 @@ rend_hsaddress_str_or_unknown(const char *onion_address)
   return str_ret;
 }
 
+/** Deliver an HS_DESC event to the event callback, if it's interested in it.
+ * The arguments are the fields of the control event, NULL if absent. */
+static void
+control_event_hs_descriptor_hook(const char *action,
+                                 const char *onion_address,
+                                 const char *auth_type,
+                                 const char *hsdir,
+                                 const char *desc_id,
+                                 const char *reason)
+{
+  tor_hs_desc_event_t ev = {
+    .action = action,
+    .address = onion_address,
+    .auth_type = auth_type,
+    .hsdir = hsdir,
+    .desc_id = desc_id,
+    .reason = reason,
+  };
+  EVENT_HOOK_SEND(TOR_EVENT_HS_DESC, &ev);
+}
+
 /** send HS_DESC requested event.
  *
  * <b>rend_query</b> is used to fetch requested onion address and auth type.
@@ -7474,6 +7745,11 @@ control_event_hs_descriptor_requested(const char *onion_address,
     tor_asprintf(&hsdir_index_field, " HSDIR_INDEX=%s", hsdir_index);
   }
 
+  control_event_hs_descriptor_hook("REQUESTED",
+                            rend_hsaddress_str_or_unknown(onion_address),
+                            rend_auth_type_to_string(auth_type),
+                            node_describe_longname_by_id(id_digest),
+                            desc_id, NULL);
   send_control_event(EVENT_HS_DESC,
                      "650 HS_DESC REQUESTED %s %s %s %s%s\r\n",
                      rend_hsaddress_str_or_unknown(onion_address),
@@ -7550,6 +7826,8 @@ control_event_hs_descriptor_created(const char *onion_address,
     tor_asprintf(&replica_field, " REPLICA=%d", replica);
   }
 
+  control_event_hs_descriptor_hook("CREATED", onion_address, "UNKNOWN",
+                                   "UNKNOWN", desc_id, NULL);
   send_control_event(EVENT_HS_DESC,
                      "650 HS_DESC CREATED %s UNKNOWN UNKNOWN %s%s\r\n",
                      onion_address, desc_id,
@@ -7579,6 +7857,9 @@ control_event_hs_descriptor_upload(const char *onion_address,
     tor_asprintf(&hsdir_index_field, " HSDIR_INDEX=%s", hsdir_index);
   }
 
+  control_event_hs_descriptor_hook("UPLOAD", onion_address, "UNKNOWN",
+                                   node_describe_longname_by_id(id_digest),
+                                   desc_id, NULL);
   send_control_event(EVENT_HS_DESC,
                      "650 HS_DESC UPLOAD %s UNKNOWN %s %s%s\r\n",
                      onion_address,
@@ -7615,6 +7896,15 @@ event_hs_descriptor_receive_end(const char *action,
     tor_asprintf(&reason_field, " REASON=%s", reason);
   }
 
+  /* The descriptor ID is formatted with a leading space, skip it */
+  control_event_hs_descriptor_hook(action,
+                            rend_hsaddress_str_or_unknown(onion_address),
+                            rend_auth_type_to_string(auth_type),
+                            hsdir_id_digest ?
+                              node_describe_longname_by_id(hsdir_id_digest) :
+                              "UNKNOWN",
+                            desc_id ? desc_id + strspn(desc_id, " ") : NULL,
+                            reason);
   send_control_event(EVENT_HS_DESC,
                      "650 HS_DESC %s %s %s %s%s%s\r\n",
                      action,
@@ -7653,6 +7943,11 @@ control_event_hs_descriptor_upload_end(const char *action,
     tor_asprintf(&reason_field, " REASON=%s", reason);
   }
 
+  control_event_hs_descriptor_hook(action,
+                            rend_hsaddress_str_or_unknown(onion_address),
+                            "UNKNOWN",
+                            node_describe_longname_by_id(id_digest),
+                            NULL, reason);
   send_control_event(EVENT_HS_DESC,
                      "650 HS_DESC %s %s UNKNOWN %s%s\r\n",
                      action,
@@ -7848,6 +8143,21 @@ control_event_hs_descriptor_upload_failed(const char *id_digest,
                                          id_digest, reason);
 }
 
+/** Send the events tor emits to <b>cb</b> in-process, in addition to the
+ * interested controllers. */
+void
+control_set_event_callback(tor_event_callback_t cb)
+{
+  event_callback = cb;
+}
+
+/** Select the event types delivered to the event callback. */
+void
+tor_set_event_mask(unsigned int mask)
+{
+  __atomic_store_n(&event_callback_mask, mask, __ATOMIC_RELAXED);
+}
+
 /** Free any leftover allocated memory of the control.c subsystem. */
 void
 control_free_all(void)
@@ -7881,6 +8191,7 @@ control_free_all(void)
   }
   bootstrap_percent = BOOTSTRAP_STATUS_UNDEF;
   bootstrap_phase = BOOTSTRAP_STATUS_UNDEF;
+  event_callback = NULL;
   notice_bootstrap_percent = 0;
   bootstrap_problems = 0;
   bootstrap_first_orconn = 0;
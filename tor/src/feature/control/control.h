@@ -12,6 +12,8 @@
 #ifndef TOR_CONTROL_H
 #define TOR_CONTROL_H
 
+#include "feature/api/tor_api.h"
+
 /** Used to indicate the type of a circuit event passed to the controller.
  * The various types are defined in control-spec.txt */
 typedef enum circuit_status_event_t {
@@ -248,6 +250,7 @@ void control_event_hs_descriptor_content(const char *onion_address,
                                          const char *hsdir_fp,
                                          const char *content);
 void control_free_all(void);
+void control_set_event_callback(tor_event_callback_t cb);
 
 #ifdef CONTROL_PRIVATE
 #include "lib/crypt_ops/crypto_ed25519.h"
@@ -16,6 +16,7 @@
 #include "app/config/config.h"
 #include "core/or/circuitbuild.h"
 #include "core/or/policies.h"
+#include "feature/api/tor_api.h"
 #include "feature/dirauth/shared_random_state.h"
 #include "feature/hs/hs_cache.h"
 #include "feature/hs/hs_circuitmap.h"
@@ -1012,6 +1013,106 @@ hs_build_address(const ed25519_public_key_t *key, uint8_t version,
   tor_assert(hs_address_is_valid(addr_out));
 }
 
+/* Make sure the ed25519 implementation is chosen, as the onion address API
+ * may be used before tor is started, from any thread. */
+static void
+onion_api_init(void)
+{
+  static int initialized = 0;
+
+  if (!__atomic_load_n(&initialized, __ATOMIC_ACQUIRE)) {
+    ed25519_init();
+    __atomic_store_n(&initialized, 1, __ATOMIC_RELEASE);
+  }
+}
+
+/* Return 1 if address is the lowercase base32 encoding of a service address
+ * that hs_parse_address() can decode without warnings, else 0. */
+static int
+onion_api_address_is_decodable(const char *address)
+{
+  return strlen(address) == HS_SERVICE_ADDR_LEN_BASE32 &&
+         strspn(address, BASE32_CHARS) == HS_SERVICE_ADDR_LEN_BASE32;
+}
+
+/* Public API: build the v3 onion address of the pubkey. Keys are validated
+ * beforehand, as hs_build_address() asserts on the ones it can't encode. */
+int
+tor_onion_build_address(const unsigned char *pubkey, char *address_out)
+{
+  ed25519_public_key_t key;
+
+  onion_api_init();
+
+  memcpy(key.pubkey, pubkey, ED25519_PUBKEY_LEN);
+  if (!ed25519_pubkey_is_valid(&key)) {
+    return TOR_ONION_BAD_KEY;
+  }
+  hs_build_address(&key, HS_VERSION_THREE, address_out);
+  return TOR_ONION_OK;
+}
+
+/* Public API: parse and validate the v3 onion address, the same way as
+ * hs_address_is_valid() but requiring version 3 and without logging the
+ * problems of the (likely user provided) address. */
+int
+tor_onion_parse_address(const char *address, unsigned char *pubkey_out)
+{
+  uint8_t version;
+  uint8_t checksum[HS_SERVICE_ADDR_CHECKSUM_LEN_USED];
+  uint8_t target_checksum[DIGEST256_LEN];
+  ed25519_public_key_t key;
+
+  onion_api_init();
+
+  if (!onion_api_address_is_decodable(address) ||
+      hs_parse_address(address, &key, checksum, &version) < 0) {
+    return TOR_ONION_MALFORMED;
+  }
+  build_hs_checksum(&key, version, target_checksum);
+  if (tor_memneq(checksum, target_checksum, sizeof(checksum))) {
+    return TOR_ONION_BAD_CHECKSUM;
+  }
+  if (version != HS_VERSION_THREE) {
+    return TOR_ONION_BAD_VERSION;
+  }
+  if (!ed25519_pubkey_is_valid(&key)) {
+    return TOR_ONION_BAD_KEY;
+  }
+  memcpy(pubkey_out, key.pubkey, ED25519_PUBKEY_LEN);
+  return TOR_ONION_OK;
+}
+
+/* Public API: blind the identity pubkey for the time period. This is the
+ * same as hs_build_blinded_pubkey(), but with the length of the period given
+ * instead of taken from the consensus, only accessible from the main loop. */
+int
+tor_onion_blind_pubkey(const unsigned char *pubkey,
+                       unsigned long long period_num,
+                       unsigned long long period_length,
+                       unsigned char *blinded_out)
+{
+  /* Our blinding key API requires a 32 bytes parameter. */
+  uint8_t param[DIGEST256_LEN];
+  ed25519_public_key_t key, blinded;
+  int ret = TOR_ONION_OK;
+
+  onion_api_init();
+
+  memcpy(key.pubkey, pubkey, ED25519_PUBKEY_LEN);
+  if (!ed25519_pubkey_is_valid(&key)) {
+    return TOR_ONION_BAD_KEY;
+  }
+  build_blinded_key_param(&key, NULL, 0, period_num, period_length, param);
+  if (ed25519_public_blind(&blinded, &key, param) < 0) {
+    ret = TOR_ONION_BAD_KEY;
+  } else {
+    memcpy(blinded_out, blinded.pubkey, ED25519_PUBKEY_LEN);
+  }
+  memwipe(param, 0, sizeof(param));
+  return ret;
+}
+
 /* Return a newly allocated copy of lspec. */
 link_specifier_t *
 hs_link_specifier_dup(const link_specifier_t *lspec)
@@ -78,6 +78,7 @@
 #include "feature/nodelist/routerlist.h"
 #include "feature/nodelist/torcert.h"
 #include "feature/relay/routermode.h"
+#include "lib/crypt_ops/crypto_format.h"
 #include "lib/crypt_ops/crypto_rand.h"
 #include "lib/crypt_ops/crypto_util.h"
 
@@ -90,6 +91,7 @@
 #include "feature/dircommon/dir_connection_st.h"
 #include "feature/dirclient/dir_server_st.h"
 #include "feature/nodelist/document_signature_st.h"
+#include "feature/nodelist/microdesc_st.h"
 #include "feature/nodelist/networkstatus_st.h"
 #include "feature/nodelist/networkstatus_voter_info_st.h"
 #include "feature/dirauth/ns_detached_signatures_st.h"
@@ -2315,6 +2317,39 @@ networkstatus_getinfo_helper_single(const routerstatus_t *rs)
                                    NULL);
 }
 
+/** Alloc and return a string describing the relays listed in the consensus
+ * <b>ns</b>: the status of each one as for the control port, followed by the
+ * digest and the body of its microdescriptor if we have it. */
+static char *
+networkstatus_getinfo_relays(const networkstatus_t *ns)
+{
+  smartlist_t *chunks = smartlist_new();
+  char *answer;
+
+  SMARTLIST_FOREACH_BEGIN(ns->routerstatus_list, const routerstatus_t *, rs) {
+    char *status = networkstatus_getinfo_helper_single(rs);
+    const node_t *node = node_get_by_id(rs->identity_digest);
+    const microdesc_t *md = node ? node->md : NULL;
+
+    if (!status)
+      continue;
+    smartlist_add(chunks, status);
+    if (md && md->body && md->bodylen) {
+      char digest64[BASE64_DIGEST256_LEN+1];
+      digest256_to_base64(digest64, md->digest);
+      smartlist_add_asprintf(chunks, "m %s\n", digest64);
+      smartlist_add(chunks, tor_strndup(md->body, md->bodylen));
+      if (md->body[md->bodylen-1] != '\n')
+        smartlist_add_strdup(chunks, "\n");
+    }
+  } SMARTLIST_FOREACH_END(rs);
+
+  answer = smartlist_join_strings(chunks, "", 0, NULL);
+  SMARTLIST_FOREACH(chunks, char *, cp, tor_free(cp));
+  smartlist_free(chunks);
+  return answer;
+}
+
 /** Alloc and return a string describing routerstatuses for the most
  * recent info of each router we know about that is of purpose
  * <b>purpose_string</b>. Return NULL if unrecognized purpose.
@@ -2584,7 +2619,10 @@ getinfo_helper_networkstatus(control_connection_t *conn,
   const routerstatus_t *status;
   (void) conn;
 
-  if (!networkstatus_get_latest_consensus()) {
+  /* Only the ns/ questions answer empty without a consensus, the consensus/
+   * ones report its absence. */
+  if (!strcmpstart(question, "ns/") &&
+      !networkstatus_get_latest_consensus()) {
     *answer = tor_strdup("");
     return 0;
   }
@@ -2624,6 +2662,29 @@ getinfo_helper_networkstatus(control_connection_t *conn,
     else
       *errmsg = "No consensus available";
     return *answer ? 0 : -1;
+  } else if (!strcmp(question, "consensus/params") ||
+             !strcmp(question, "consensus/bandwidth-weights")) {
+    const networkstatus_t *ns = networkstatus_get_latest_consensus();
+    smartlist_t *params;
+    if (!ns) {
+      *errmsg = "No consensus available";
+      return -1;
+    }
+    params = !strcmp(question, "consensus/params") ?
+      ns->net_params : ns->weight_params;
+    if (params)
+      *answer = smartlist_join_strings(params, " ", 0, NULL);
+    else
+      *answer = tor_strdup("");
+    return 0;
+  } else if (!strcmp(question, "consensus/relays")) {
+    const networkstatus_t *ns = networkstatus_get_latest_consensus();
+    if (!ns) {
+      *errmsg = "No consensus available";
+      return -1;
+    }
+    *answer = networkstatus_getinfo_relays(ns);
+    return 0;
   } else if (!strcmp(question, "consensus/valid-after") ||
              !strcmp(question, "consensus/fresh-until") ||
              !strcmp(question, "consensus/valid-until")) {
@@ -31,6 +31,8 @@
 #include "feature/relay/router.h"
 #include "feature/relay/routermode.h"
 #include "lib/crypt_ops/crypto_rand.h"
+#include "lib/encoding/binascii.h"
+#include "lib/geoip/geoip.h"
 #include "lib/math/fp.h"
 
 #include "feature/dirclient/dir_server_st.h"
@@ -480,6 +482,110 @@ kb_to_bytes(uint32_t bw)
   return (bw > (INT32_MAX/1000)) ? INT32_MAX : bw*1000;
 }
 
+/** Callback of the embedding application weighting the candidates of path
+ * positions, or NULL if there is none. */
+static tor_path_callback_t path_callback = NULL;
+/** Whether the path callback is consulted. It is set from the embedding
+ * application's threads, hence the atomic accesses. */
+static int path_callback_enabled = 0;
+/** Position in a new circuit's path the nodes are being chosen for, one of
+ * TOR_PATH_*, or 0 if the nodes are chosen for something else. */
+static int path_position = 0;
+/** Control protocol purpose of the circuit whose path is being chosen. */
+static const char *path_purpose = NULL;
+
+/** Upper bound of the weights assigned by the path callback, keeping their
+ * sum well within the range of doubles. */
+#define PATH_CALLBACK_MAX_WEIGHT 1e18
+
+/** Set the callback weighting the candidates of path positions. */
+void
+node_select_set_path_callback(tor_path_callback_t cb)
+{
+  path_callback = cb;
+}
+
+/** Enable or disable consulting the path callback. */
+void
+tor_set_path_callback_enabled(int enabled)
+{
+  __atomic_store_n(&path_callback_enabled, enabled, __ATOMIC_RELAXED);
+}
+
+/** Mark the nodes chosen from now on as candidates for <b>position</b>, one
+ * of TOR_PATH_*, in the path of a circuit with the control protocol
+ * <b>purpose</b>. A <b>position</b> of 0 ends the path selection. */
+void
+node_select_set_path_position(int position, const char *purpose)
+{
+  path_position = position;
+  path_purpose = purpose;
+}
+
+/** Return true iff nodes are being chosen for a path position, and the path
+ * callback wants to weigh them. */
+static int
+path_callback_is_enabled(void)
+{
+  return path_position && path_callback &&
+    __atomic_load_n(&path_callback_enabled, __ATOMIC_RELAXED);
+}
+
+/** Let the path callback weigh or veto the candidates <b>sl</b> of the path
+ * position being chosen, updating their <b>bandwidths</b>. Vetoed candidates
+ * get a weight of 0; if the ones left all have a weight of 0, they are given
+ * equal weights instead, as choose_array_element_by_weight() would. Return
+ * the number of candidates left. */
+static int
+path_callback_weigh(const smartlist_t *sl, double *bandwidths)
+{
+  tor_path_candidate_t *candidates;
+  int i, n = smartlist_len(sl), left = 0, weighted = 0;
+
+  candidates = tor_calloc(n, sizeof(tor_path_candidate_t));
+  SMARTLIST_FOREACH_BEGIN(sl, const node_t *, node) {
+    tor_path_candidate_t *candidate = &candidates[node_sl_idx];
+    const char *nickname = node_get_nickname(node);
+    tor_addr_t addr;
+
+    base16_encode(candidate->fingerprint, sizeof(candidate->fingerprint),
+                  node->identity, DIGEST_LEN);
+    candidate->nickname = nickname ? nickname : "";
+    node_get_addr(node, &addr);
+    if (!tor_addr_is_null(&addr))
+      tor_addr_to_str(candidate->address, &addr,
+                      sizeof(candidate->address), 0);
+    strlcpy(candidate->country, geoip_get_country_name(node->country),
+            sizeof(candidate->country));
+    candidate->weight = bandwidths[node_sl_idx];
+  } SMARTLIST_FOREACH_END(node);
+
+  path_callback(path_position, path_purpose ? path_purpose : "",
+                candidates, n);
+
+  for (i = 0; i < n; ++i) {
+    double weight = candidates[i].weight;
+    if (candidates[i].veto) {
+      bandwidths[i] = 0;
+      continue;
+    }
+    if (!(weight >= 0)) /* Negative or NaN */
+      weight = 0;
+    else if (weight > PATH_CALLBACK_MAX_WEIGHT)
+      weight = PATH_CALLBACK_MAX_WEIGHT;
+    bandwidths[i] = weight;
+    left++;
+    weighted += weight > 0;
+  }
+  if (left && !weighted) {
+    for (i = 0; i < n; ++i)
+      if (!candidates[i].veto)
+        bandwidths[i] = 1;
+  }
+  tor_free(candidates);
+  return left;
+}
+
 /** Helper function:
  * choose a random element of smartlist <b>sl</b> of nodes, weighted by
  * the advertised bandwidth of each element using the consensus
@@ -504,6 +610,12 @@ smartlist_choose_node_by_bandwidth_weights(const smartlist_t *sl,
   if (compute_weighted_bandwidths(sl, rule, &bandwidths_dbl, NULL) < 0)
     return NULL;
 
+  if (path_callback_is_enabled() &&
+      path_callback_weigh(sl, bandwidths_dbl) == 0) {
+    tor_free(bandwidths_dbl);
+    return NULL;
+  }
+
   bandwidths_u64 = tor_calloc(smartlist_len(sl), sizeof(uint64_t));
   scale_array_elements_to_u64(bandwidths_u64, bandwidths_dbl,
                               smartlist_len(sl), NULL);
@@ -11,6 +11,8 @@
 #ifndef TOR_NODE_SELECT_H
 #define TOR_NODE_SELECT_H
 
+#include "feature/api/tor_api.h"
+
 /** Flags to be passed to control router_choose_random_node() to indicate what
  * kind of nodes to pick according to what algorithm. */
 typedef enum router_crn_flags_t {
@@ -85,6 +87,9 @@ const routerstatus_t *router_pick_trusteddirserver(dirinfo_type_t type,
 const routerstatus_t *router_pick_fallback_dirserver(dirinfo_type_t type,
                                                      int flags);
 
+void node_select_set_path_callback(tor_path_callback_t cb);
+void node_select_set_path_position(int position, const char *purpose);
+
 #ifdef NODE_SELECT_PRIVATE
 STATIC int choose_array_element_by_weight(const uint64_t *entries,
                                           int n_entries);
@@ -9,6 +9,7 @@
 #include "app/config/config.h"
 #include "core/mainloop/connection.h"
 #include "core/or/port_cfg_st.h"
+#include "feature/api/tor_api_conn.h"
 #include "feature/relay/router.h"
 #include "feature/relay/routermode.h"
 
@@ -30,6 +31,9 @@ int
 proxy_mode(const or_options_t *options)
 {
   (void)options;
+  /* Connections handed over by the embedding application need no port. */
+  if (tor_api_conn_active())
+    return 1;
   SMARTLIST_FOREACH_BEGIN(get_configured_ports(), const port_cfg_t *, p) {
     if (p->type == CONN_TYPE_AP_LISTENER ||
         p->type == CONN_TYPE_AP_TRANS_LISTENER ||
@@ -790,17 +790,16 @@ ed25519_point_is_identity_element(const uint8_t *point)
   return tor_memeq(point, ed25519_identity, sizeof(ed25519_identity));
 }
 
-/** Validate <b>pubkey</b> to ensure that it has no torsion component.
- *  Return 0 if <b>pubkey</b> is valid, else return -1. */
-int
-ed25519_validate_pubkey(const ed25519_public_key_t *pubkey)
+/** Check that <b>pubkey</b> has no torsion component. Return NULL if
+ *  <b>pubkey</b> is valid, else a description of the problem. */
+static const char *
+ed25519_pubkey_problem(const ed25519_public_key_t *pubkey)
 {
   uint8_t result[32] = {0};
 
   /* First check that we were not given the identity element */
   if (ed25519_point_is_identity_element(pubkey->pubkey)) {
-    log_warn(LD_CRYPTO, "ed25519 pubkey is the identity");
-    return -1;
+    return "ed25519 pubkey is the identity";
   }
 
   /* For any point on the curve, doing l*point should give the identity element
@@ -808,14 +807,35 @@ ed25519_validate_pubkey(const ed25519_public_key_t *pubkey)
    * identity element is returned. */
   if (get_ed_impl()->ed25519_scalarmult_with_group_order(result,
                                                          pubkey->pubkey) < 0) {
-    log_warn(LD_CRYPTO, "ed25519 group order scalarmult failed");
-    return -1;
+    return "ed25519 group order scalarmult failed";
   }
 
   if (!ed25519_point_is_identity_element(result)) {
-    log_warn(LD_CRYPTO, "ed25519 validation failed");
-    return -1;
+    return "ed25519 validation failed";
   }
 
+  return NULL;
+}
+
+/** Validate <b>pubkey</b> to ensure that it has no torsion component.
+ *  Return 0 if <b>pubkey</b> is valid, else return -1. */
+int
+ed25519_validate_pubkey(const ed25519_public_key_t *pubkey)
+{
+  const char *problem = ed25519_pubkey_problem(pubkey);
+
+  if (problem) {
+    log_warn(LD_CRYPTO, "%s", problem);
+    return -1;
+  }
   return 0;
 }
+
+/** As ed25519_validate_pubkey(), but without logging anything, for keys
+ *  that are expected to be invalid at times (e.g. entered by a user).
+ *  Return 1 if <b>pubkey</b> is valid, else return 0. */
+int
+ed25519_pubkey_is_valid(const ed25519_public_key_t *pubkey)
+{
+  return ed25519_pubkey_problem(pubkey) == NULL;
+}
@@ -79,6 +79,7 @@ typedef struct logfile_t {
   int seems_dead; /**< Boolean: true if the stream seems to be kaput. */
   int needs_close; /**< Boolean: true if the stream gets closed on shutdown. */
   int is_temporary; /**< Boolean: close after initializing logging subsystem.*/
+  int is_permanent; /**< Boolean: never close when reconfiguring the logs. */
   int is_syslog; /**< Boolean: send messages to syslog. */
   int is_android; /**< Boolean: send messages to Android's log subsystem. */
   char *android_tag; /**< Identity Tag used in Android's log subsystem. */
@@ -961,11 +962,12 @@ logs_set_pending_callback_callback(pending_callback_callback cb)
 }
 
 /**
- * Add a log handler to send messages in <b>severity</b>
- * to the function <b>cb</b>.
+ * Helper: add a log handler to send messages in <b>severity</b> to the
+ * function <b>cb</b>, making it permanent if <b>permanent</b> is set.
  */
-int
-add_callback_log(const log_severity_list_t *severity, log_callback cb)
+static int
+add_callback_log_impl(const log_severity_list_t *severity, log_callback cb,
+                      int permanent)
 {
   logfile_t *lf;
   lf = tor_malloc_zero(sizeof(logfile_t));
@@ -973,15 +975,38 @@ add_callback_log(const log_severity_list_t *severity, log_callback cb)
   lf->severities = tor_memdup(severity, sizeof(log_severity_list_t));
   lf->filename = tor_strdup("<callback>");
   lf->callback = cb;
-  lf->next = logfiles;
+  lf->is_permanent = permanent;
 
   LOCK_LOGS();
+  lf->next = logfiles;
   logfiles = lf;
   log_global_min_severity_ = get_min_log_level();
   UNLOCK_LOGS();
   return 0;
 }
 
+/**
+ * Add a log handler to send messages in <b>severity</b>
+ * to the function <b>cb</b>.
+ */
+int
+add_callback_log(const log_severity_list_t *severity, log_callback cb)
+{
+  return add_callback_log_impl(severity, cb, 0);
+}
+
+/**
+ * As add_callback_log(), but the handler survives the reconfiguration of the
+ * logs, only being closed by logs_free_all(). Since it receives the messages
+ * logged during startup directly, they are not replayed to it either.
+ */
+int
+add_permanent_callback_log(const log_severity_list_t *severity,
+                           log_callback cb)
+{
+  return add_callback_log_impl(severity, cb, 1);
+}
+
 /** Adjust the configured severity of any logs whose callback function is
  * <b>cb</b>. */
 void
@@ -1068,6 +1093,10 @@ flush_log_messages_from_startup(void)
       if (lf->fd == STDOUT_FILENO || lf->fd == STDERR_FILENO) {
         continue;
       }
+      /* Permanent logs were already live during startup */
+      if (lf->is_permanent) {
+        continue;
+      }
 
       logfile_deliver(lf, msg->fullmsg, strlen(msg->fullmsg), msg->msg,
                       msg->domain, msg->severity, &callbacks_deferred);
@@ -1113,7 +1142,8 @@ rollback_log_changes(void)
   logfile_t *lf;
   LOCK_LOGS();
   for (lf = logfiles; lf; lf = lf->next)
-    lf->is_temporary = ! lf->is_temporary;
+    if (! lf->is_permanent)
+      lf->is_temporary = ! lf->is_temporary;
   UNLOCK_LOGS();
   close_temp_logs();
 }
@@ -1125,7 +1155,8 @@ mark_logs_temp(void)
   logfile_t *lf;
   LOCK_LOGS();
   for (lf = logfiles; lf; lf = lf->next)
-    lf->is_temporary = 1;
+    if (! lf->is_permanent)
+      lf->is_temporary = 1;
   UNLOCK_LOGS();
 }
 
@@ -0,0 +1,229 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth is the maximum nesting of %include directives, matching the
+// limit tor itself enforces.
+const maxIncludeDepth = 31
+
+// Torrc is an in-memory tor configuration, used instead of the torrc and defaults
+// torrc files that tor would otherwise read from disk.
+//
+// The %include directives within the configurations are resolved against the
+// Include file system, without tor touching the disk. Absolute include paths are
+// interpreted relative to the root of the file system. Any http.FileSystem can be
+// used, e.g. http.Dir for a directory on disk, or IncludeFS wrapping an fs.FS.
+//
+// The file system is an http.FileSystem rather than an fs.FS as the package
+// still supports Go 1.12, which predates io/fs.
+type Torrc struct {
+	Config   string          // Contents of the torrc
+	Defaults string          // Contents of the defaults torrc
+	Include  http.FileSystem // File system to resolve %include directives against, nil to reject them
+}
+
+// ReadTorrc creates an in-memory tor configuration from the contents of a torrc
+// and a defaults torrc. Either reader may be nil to leave that file empty.
+func ReadTorrc(config, defaults io.Reader, include http.FileSystem) (*Torrc, error) {
+	torrc := &Torrc{Include: include}
+	if config != nil {
+		blob, err := ioutil.ReadAll(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read torrc: %v", err)
+		}
+		torrc.Config = string(blob)
+	}
+	if defaults != nil {
+		blob, err := ioutil.ReadAll(defaults)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read defaults torrc: %v", err)
+		}
+		torrc.Defaults = string(blob)
+	}
+	return torrc, nil
+}
+
+// expand resolves all the %include directives in the torrc and defaults torrc,
+// returning the configurations to hand over to tor.
+func (t *Torrc) expand() (string, string, error) {
+	config, err := t.expandIncludes(t.Config, 0)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to expand torrc: %v", err)
+	}
+	defaults, err := t.expandIncludes(t.Defaults, 0)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to expand defaults torrc: %v", err)
+	}
+	return config, defaults, nil
+}
+
+// expandIncludes replaces the %include directives in a configuration with the
+// contents of the referenced files, recursively.
+func (t *Torrc) expandIncludes(config string, depth int) (string, error) {
+	if depth > maxIncludeDepth {
+		return "", fmt.Errorf("more than %d nested %%includes", maxIncludeDepth)
+	}
+	var (
+		expanded  strings.Builder
+		continued bool // Whether the line is the continuation of the previous one
+	)
+	for _, line := range strings.SplitAfter(config, "\n") {
+		target, ok := "", false
+		if !continued {
+			target, ok = parseInclude(line)
+		}
+		continued = strings.HasSuffix(strings.TrimRight(line, "\r\n"), "\\")
+
+		if !ok {
+			expanded.WriteString(line)
+			continue
+		}
+		if t.Include == nil {
+			return "", fmt.Errorf("%%include %q without include file system", target)
+		}
+		files, err := includedFiles(t.Include, target)
+		if err != nil {
+			return "", fmt.Errorf("%%include %q: %v", target, err)
+		}
+		for _, file := range files {
+			blob, err := readIncluded(t.Include, file)
+			if err != nil {
+				return "", fmt.Errorf("%%include %q: %v", target, err)
+			}
+			included, err := t.expandIncludes(string(blob), depth+1)
+			if err != nil {
+				return "", err
+			}
+			expanded.WriteString(included)
+			if included != "" && !strings.HasSuffix(included, "\n") {
+				expanded.WriteString("\n")
+			}
+		}
+	}
+	return expanded.String(), nil
+}
+
+// parseInclude checks whether a configuration line is an %include directive and
+// if so, returns the path it references.
+func parseInclude(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "%include") {
+		return "", false
+	}
+	value := strings.TrimPrefix(line, "%include")
+	if value != "" && value[0] != ' ' && value[0] != '\t' {
+		return "", false // Some other keyword with the same prefix
+	}
+	value = strings.TrimSpace(value)
+
+	// Quoted values are C escaped, otherwise a comment may end the line
+	if strings.HasPrefix(value, "\"") {
+		if quoted, ok := quotedPrefix(value); ok {
+			if unquoted, err := strconv.Unquote(quoted); err == nil {
+				return unquoted, true
+			}
+		}
+		return value, true
+	}
+	if idx := strings.IndexByte(value, '#'); idx >= 0 {
+		value = strings.TrimSpace(value[:idx])
+	}
+	return value, true
+}
+
+// quotedPrefix returns the double quoted string at the start of a value, along
+// with its quotes, skipping over backslash escaped characters.
+func quotedPrefix(value string) (string, bool) {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return value[:i+1], true
+		}
+	}
+	return "", false
+}
+
+// includedFiles lists the files an %include directive references. Directories
+// include all the regular files within them, in lexical order, except for the
+// hidden ones, same as tor does.
+func includedFiles(fsys http.FileSystem, target string) ([]string, error) {
+	if target == "" {
+		return nil, errors.New("empty path")
+	}
+	name := path.Clean(strings.TrimPrefix(filepath.ToSlash(target), "/"))
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return nil, errors.New("path outside of include file system")
+	}
+	name = path.Join("/", name)
+
+	dir, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	info, err := dir.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{name}, nil
+	}
+	entries, err := dir.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var files []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		file := path.Join(name, entry.Name())
+		if info, err := statIncluded(fsys, file); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// statIncluded returns the file info of a file in the include file system.
+func statIncluded(fsys http.FileSystem, name string) (os.FileInfo, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return file.Stat()
+}
+
+// readIncluded reads the contents of a file in the include file system.
+func readIncluded(fsys http.FileSystem, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ioutil.ReadAll(file)
+}
@@ -0,0 +1,18 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+//go:build go1.16
+// +build go1.16
+
+package libtor
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+// IncludeFS adapts an fs.FS (e.g. an embed.FS) into the file system the %include
+// directives of an in-memory torrc are resolved against.
+func IncludeFS(fsys fs.FS) http.FileSystem {
+	return http.FS(fsys)
+}
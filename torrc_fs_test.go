@@ -0,0 +1,32 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+//go:build go1.16
+// +build go1.16
+
+package libtor
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+// Tests that %include directives are resolved against an fs.FS via IncludeFS,
+// directories including their visible files in lexical order.
+func TestIncludeFS(t *testing.T) {
+	torrc := &Torrc{
+		Config: "SocksPort 0\n%include /torrc.d\n",
+		Include: IncludeFS(fstest.MapFS{
+			"torrc.d/b.conf":  {Data: []byte("Nickname b\n")},
+			"torrc.d/a.conf":  {Data: []byte("ContactInfo a")},
+			"torrc.d/.hidden": {Data: []byte("Nickname hidden\n")},
+		}),
+	}
+	config, _, err := torrc.expand()
+	if err != nil {
+		t.Fatalf("failed to expand torrc: %v", err)
+	}
+	if want := "SocksPort 0\nContactInfo a\nNickname b\n"; config != want {
+		t.Errorf("expanded torrc mismatch: have %q, want %q", config, want)
+	}
+}
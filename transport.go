@@ -0,0 +1,181 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// maxKeyedTransports is the number of custom isolation keys whose connections
+// are kept pooled, the least recently used ones being dropped beyond it.
+const maxKeyedTransports = 64
+
+// Transport is an http.RoundTripper sending requests through the embedded tor.
+// Hostnames (including .onion addresses) are resolved by tor, never locally, and
+// proxy settings from the environment are ignored.
+//
+// By default, requests to different hosts are sent over separate circuits.
+type Transport struct {
+	// Isolation returns the isolation key of a request, requests with different
+	// keys being sent over separate circuits. If nil, requests are isolated per
+	// destination host. Idle connections are only kept for the most recently
+	// used keys.
+	Isolation func(req *http.Request) string
+
+	// Retries is the number of times an idempotent request is retried on a fresh
+	// circuit if tor failed to open its stream for a circuit specific reason (exit
+	// policy reject, timeout or destroyed circuit).
+	Retries int
+
+	dialer *Dialer
+	hosts  *http.Transport          // Transport shared by the per-host isolated requests
+	keyed  map[string]*list.Element // Transports of the custom isolated requests
+	lru    *list.List               // Custom isolation keys, most recently used first
+	lock   sync.Mutex
+}
+
+// keyedTransport is the HTTP transport of a custom isolation key.
+type keyedTransport struct {
+	key       string
+	transport *http.Transport
+}
+
+// Transport returns an HTTP transport sending requests through the embedded tor,
+// retrying failed idempotent requests twice.
+func (t *Tor) Transport() *Transport {
+	return &Transport{
+		Retries: 2,
+		dialer:  t.Dialer(),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var key string
+	if t.Isolation != nil {
+		key = t.Isolation(req)
+	} else {
+		key = req.URL.Hostname()
+	}
+	transport := t.transport(key)
+
+	for attempt := 0; ; attempt++ {
+		// Retries need a fresh circuit, so isolate them from the failed attempts
+		isolated := key
+		if attempt > 0 {
+			isolated = fmt.Sprintf("%s#%d", key, attempt)
+		}
+		areq := req.WithContext(WithIsolation(req.Context(), isolated))
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			areq.Body = body
+		}
+		res, err := transport.RoundTrip(areq)
+		if err == nil || attempt >= t.Retries || !retriable(req, err) {
+			return res, err
+		}
+	}
+}
+
+// CloseIdleConnections closes the connections not carrying requests, dropping the
+// transports of the custom isolation keys too.
+func (t *Transport) CloseIdleConnections() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.hosts != nil {
+		t.hosts.CloseIdleConnections()
+	}
+	for _, elem := range t.keyed {
+		elem.Value.(*keyedTransport).transport.CloseIdleConnections()
+	}
+	t.keyed, t.lru = nil, nil
+}
+
+// transport returns the HTTP transport to send requests with the given isolation
+// key over. Connections are pooled per host, which is enough for the per-host
+// isolation, but custom keys need a pool of their own. The pools of the least
+// recently used keys are dropped, their connections closed once idle.
+func (t *Transport) transport(key string) *http.Transport {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.Isolation == nil {
+		if t.hosts == nil {
+			t.hosts = t.newTransport()
+		}
+		return t.hosts
+	}
+	if t.keyed == nil {
+		t.keyed, t.lru = make(map[string]*list.Element), list.New()
+	}
+	if elem, ok := t.keyed[key]; ok {
+		t.lru.MoveToFront(elem)
+		return elem.Value.(*keyedTransport).transport
+	}
+	transport := t.newTransport()
+	t.keyed[key] = t.lru.PushFront(&keyedTransport{key: key, transport: transport})
+
+	if t.lru.Len() > maxKeyedTransports {
+		// Requests in flight on the dropped transport are unaffected, but their
+		// connections are not reused afterwards, expiring after IdleConnTimeout
+		evicted := t.lru.Remove(t.lru.Back()).(*keyedTransport)
+		delete(t.keyed, evicted.key)
+		evicted.transport.CloseIdleConnections()
+	}
+	return transport
+}
+
+// newTransport creates an HTTP transport dialing through tor, with the isolation
+// key taken from the request contexts.
+func (t *Transport) newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:                 nil,
+		DialContext:           t.dialer.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       http.DefaultTransport.(*http.Transport).IdleConnTimeout,
+		TLSHandshakeTimeout:   http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout,
+		ExpectContinueTimeout: http.DefaultTransport.(*http.Transport).ExpectContinueTimeout,
+	}
+}
+
+// retriable reports whether a failed request may be sent again: it needs to be
+// idempotent, have a replayable body and have failed due to its stream.
+func retriable(req *http.Request, err error) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+	default:
+		if _, ok := req.Header["Idempotency-Key"]; !ok {
+			if _, ok := req.Header["X-Idempotency-Key"]; !ok {
+				return false
+			}
+		}
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if req.Context().Err() != nil {
+		return false
+	}
+	for err != nil {
+		switch e := err.(type) {
+		case *StreamError:
+			return e.temporary()
+		case *net.OpError:
+			err = e.Err // Not unwrappable before Go 1.13
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return false
+		}
+	}
+	return false
+}
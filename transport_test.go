@@ -0,0 +1,69 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// Tests that the transports of custom isolation keys are bounded, dropping the
+// least recently used ones.
+func TestTransportKeyEviction(t *testing.T) {
+	transport := &Transport{
+		Isolation: func(req *http.Request) string { return req.Host },
+		dialer:    new(Dialer),
+	}
+	keyed := make([]*http.Transport, maxKeyedTransports)
+	for i := range keyed {
+		keyed[i] = transport.transport(fmt.Sprintf("key-%d", i))
+	}
+	// Touch the first key, making the second the least recently used one
+	if transport.transport("key-0") != keyed[0] {
+		t.Fatalf("transport of used key not reused")
+	}
+	transport.transport("overflow")
+
+	if have := len(transport.keyed); have != maxKeyedTransports {
+		t.Errorf("keyed transport count mismatch: have %d, want %d", have, maxKeyedTransports)
+	}
+	if _, ok := transport.keyed["key-0"]; !ok {
+		t.Errorf("recently used transport evicted")
+	}
+	if _, ok := transport.keyed["key-1"]; ok {
+		t.Errorf("least recently used transport not evicted")
+	}
+}
+
+// Tests that only stream failures tor may recover from on a fresh circuit are
+// retried, even when wrapped by the dialer.
+func TestTransportRetriable(t *testing.T) {
+	req := &http.Request{Method: http.MethodGet, URL: &url.URL{Scheme: "http", Host: "example.com"}}
+	wrap := func(err error) error {
+		return &net.OpError{Op: "dial", Net: "tcp", Addr: torAddr("example.com:80"), Err: err}
+	}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{wrap(&StreamError{Reply: SocksGeneralFailure, Reason: EndExitPolicy}), true},
+		{wrap(&StreamError{Reply: SocksTTLExpired}), true},
+		{wrap(&StreamError{Reply: SocksHostUnreachable, Reason: EndResolveFailed}), false},
+		{wrap(errors.New("connection refused")), false},
+		{errors.New("unexpected EOF"), false},
+	}
+	for i, tt := range tests {
+		if have := retriable(req, tt.err); have != tt.want {
+			t.Errorf("test %d: retriable mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	post := &http.Request{Method: http.MethodPost, URL: req.URL, Header: make(http.Header)}
+	if retriable(post, tests[0].err) {
+		t.Errorf("non-idempotent request retriable")
+	}
+}
@@ -0,0 +1,95 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ConfigError is a configuration rejected by tor's option parsing or validation.
+type ConfigError struct {
+	Option  string // Name of the offending option, empty if it cannot be determined
+	Message string // Reason for rejecting the configuration, as reported by tor
+}
+
+// Error implements error, formatting the configuration failure.
+func (e *ConfigError) Error() string {
+	if e.Option == "" {
+		return fmt.Sprintf("invalid tor configuration: %s", e.Message)
+	}
+	return fmt.Sprintf("invalid tor configuration option %s: %s", e.Option, e.Message)
+}
+
+// ValidateConfig checks a configuration with tor's own option parsing and
+// validation logic (as with tor --verify-config), without starting the network.
+// If tor rejects the configuration, a *ConfigError is returned.
+//
+// Validation runs the embedded tor's startup code, so it cannot be done while an
+// embedded instance is running.
+func ValidateConfig(config *Config) error {
+	if config == nil {
+		config = new(Config)
+	}
+	// Validate the data directory Start would use, or an empty temporary one
+	datadir := config.DataDir
+	if datadir == "" {
+		dir, err := ioutil.TempDir("", "libtor")
+		if err != nil {
+			return fmt.Errorf("failed to create data directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		datadir = dir
+	}
+	proc, err := newProcess(context.Background(), config, datadir)
+	if err != nil {
+		return err
+	}
+	msg, err := proc.VerifyConfig()
+	if err != nil {
+		return err
+	}
+	if msg == "" {
+		return nil
+	}
+	msg = strings.TrimPrefix(msg, "Failed to parse/validate config: ")
+	return &ConfigError{Option: offendingOption(msg), Message: msg}
+}
+
+var (
+	// unknownOptionRegexp matches tor's report of an unrecognized option.
+	unknownOptionRegexp = regexp.MustCompile(`^Unknown option '([^' ]+)'`)
+
+	// quotedOptionRegexp matches the first quoted "option value" pair of tor's
+	// option parsing failures.
+	quotedOptionRegexp = regexp.MustCompile(`'([A-Za-z0-9_]+)[ ']`)
+
+	// wordRegexp matches the words of a message that could be option names.
+	wordRegexp = regexp.MustCompile(`[A-Za-z0-9_]+`)
+)
+
+// offendingOption attempts to extract the name of the rejected option from tor's
+// error message. Tor's messages are free form, so this is best effort: parsing
+// failures quote the offending line, whereas validation failures mostly mention
+// the option verbatim.
+func offendingOption(msg string) string {
+	if match := unknownOptionRegexp.FindStringSubmatch(msg); match != nil {
+		return match[1]
+	}
+	if match := quotedOptionRegexp.FindStringSubmatch(msg); match != nil {
+		if name, ok := optionNames[strings.ToLower(match[1])]; ok {
+			return name
+		}
+	}
+	for _, word := range wordRegexp.FindAllString(msg, -1) {
+		if name, ok := optionNames[strings.ToLower(word)]; ok && name == word {
+			return name
+		}
+	}
+	return ""
+}
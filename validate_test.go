@@ -0,0 +1,55 @@
+// go-libtor - Self-contained Tor from Go
+// Copyright (c) 2018 Péter Szilágyi. All rights reserved.
+
+package libtor
+
+import "testing"
+
+// Tests that the offending options are extracted from the error messages tor
+// 0.3.5 reports for rejected configurations.
+func TestOffendingOption(t *testing.T) {
+	tests := []struct {
+		msg    string
+		option string
+	}{
+		{"Unknown option 'NoSuchOption'.  Failing.", "NoSuchOption"},
+		{"Unknown option 'nosuchoption'.  Failing.", "nosuchoption"},
+		{"Interval 'MaxCircuitDirtiness abc' is malformed or out of bounds.", "MaxCircuitDirtiness"},
+		{"Interval 'maxcircuitdirtiness 10 fortnights' is malformed or out of bounds.", "MaxCircuitDirtiness"},
+		{"Boolean 'DisableNetwork 2' expects 0 or 1.", "DisableNetwork"},
+		{"Int keyword 'ConnLimit -5' is malformed or out of bounds.", "ConnLimit"},
+		{"Value 'BandwidthRate 10 bogons' is malformed or out of bounds.", "BandwidthRate"},
+		{"Invalid SocksPort configuration", "SocksPort"},
+		{"Invalid ControlPort configuration", "ControlPort"},
+		{"Error in ExitPolicy entry.", "ExitPolicy"},
+		{"Invalid exit list '{zz' for option 'ExitNodes'", "ExitNodes"},
+		{"KeepalivePeriod option must be positive.", "KeepalivePeriod"},
+		{"Failed to validate Log options. See logs for details.", "Log"},
+		{"Failed to configure rendezvous options. See logs for details.", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if have := offendingOption(tt.msg); have != tt.option {
+			t.Errorf("%q: option mismatch: have %q, want %q", tt.msg, have, tt.option)
+		}
+	}
+}
+
+// Tests that configurations rejected by tor are reported as a *ConfigError,
+// naming the offending option.
+func TestValidateConfig(t *testing.T) {
+	if err := ValidateConfig(&Config{Args: []string{"--DisableNetwork", "1"}}); err != nil {
+		t.Fatalf("valid configuration rejected: %v", err)
+	}
+	err := ValidateConfig(&Config{Args: []string{"--MaxCircuitDirtiness", "10 fortnights"}})
+	cerr, ok := err.(*ConfigError)
+	if !ok {
+		t.Fatalf("error type mismatch: have %T (%v), want *ConfigError", err, err)
+	}
+	if cerr.Option != "MaxCircuitDirtiness" {
+		t.Errorf("option mismatch: have %q, want %q", cerr.Option, "MaxCircuitDirtiness")
+	}
+	if want := "Interval 'MaxCircuitDirtiness 10 fortnights' is malformed or out of bounds."; cerr.Message != want {
+		t.Errorf("message mismatch: have %q, want %q", cerr.Message, want)
+	}
+}